@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// An App runs a command tree. It owns the root command, the standard streams
+// and the parser settings, so several apps can run side by side.
+type App struct {
+	Root      *Command  // Root command of the command tree
+	Version   string    // Version printed by --version (Default: Root.Version)
+	In        io.Reader // Standard input (Default: os.Stdin)
+	Out       io.Writer // Standard output, used for help and version texts (Default: os.Stdout)
+	Err       io.Writer // Error output, used for warnings (Default: os.Stderr)
+	HelpFlags []string  // Arguments that print the help text (Default: -h, --help, help)
+	EnvPrefix string    // If set, flags are bound to environment variables named "<EnvPrefix>_<NAME>"
+
+	// Function used to read environment variables (Default: os.LookupEnv)
+	LookupEnv func(key string) (string, bool)
+
+	// Sources for flag values not set on the command line or in the environment.
+	// Config files loaded by a ConfigFlag take precedence.
+	Sources []ValueSource
+
+	// If true, unambiguous prefixes of sub-command and long flag names are
+	// accepted, e.g. "log" for "login"
+	PrefixMatching bool
+
+	// Maximum edit distance of the commands and flags suggested for unknown
+	// ones (Default: 2)
+	SuggestionDistance int
+	// If true, unknown commands and flags aren't followed by suggestions
+	DisableSuggestions bool
+
+	// Handling of --dry-run for commands without a DryRun function
+	// (Default: DryRunRefuse)
+	DryRunPolicy DryRunPolicy
+
+	// If true, the context passed to the Runner is cancelled on SIGINT and
+	// SIGTERM. A second signal exits with ExitCodeInterrupted.
+	HandleSignals bool
+	// Time the Runner and the shutdown hooks get to return after a signal,
+	// before the process exits with ExitCodeInterrupted (Default: unlimited)
+	GracePeriod time.Duration
+	// Function used to exit the process (Default: os.Exit)
+	Exit func(code int)
+
+	middleware    []Middleware
+	shutdownHooks []func(ctx context.Context) error
+}
+
+type appContextKey struct{}
+
+// NewApp creates an App for the given root command, using the os standard
+// input, Out and Err, and the default parser settings.
+func NewApp(cmd *Command) *App {
+	return &App{
+		Root:      cmd,
+		Version:   cmd.Version,
+		In:        os.Stdin,
+		Out:       Out,
+		Err:       Err,
+		HelpFlags: defaultHelpFlags,
+		LookupEnv: os.LookupEnv,
+	}
+}
+
+// AppFromContext returns the App running the current command, or nil if the
+// context doesn't belong to a Runner.
+func AppFromContext(ctx context.Context) *App {
+	app, _ := ctx.Value(appContextKey{}).(*App)
+	return app
+}
+
+// Run resolves the command addressed by args and executes it. The args
+// should not contain the program name.
+func (a *App) Run(ctx context.Context, args []string) error {
+	ctx = context.WithValue(ctx, appContextKey{}, a)
+	cmd := a.root()
+
+	if len(args) > 0 && args[0] == "lint" {
+		errs := lint(cmd, true)
+		for _, err := range errs {
+			fmt.Fprintln(a.stderr(), "[WARN] "+err.Error())
+		}
+		return nil
+	}
+
+	params := Params{}
+	cmd, err := a.resolve(cmd, args, params)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 || cmd.showHelp || !cmd.Runnable() {
+		cmd.printHelp(a)
+		return nil
+	}
+
+	for _, c := range cmd.path() {
+		if c.Options == nil {
+			continue
+		}
+		if err := fillOptions(c.Options, params); err != nil {
+			return err
+		}
+	}
+
+	runCtx, run, err := a.runner(ctx, cmd, params)
+	if err != nil {
+		return err
+	}
+
+	runCtx, stop := a.notifySignals(runCtx)
+	defer stop()
+	err = cmd.execute(runCtx, params, run, a.middleware)
+
+	return a.shutdown(ctx, err)
+}
+
+// Use adds middleware wrapping the Run function of every command. It wraps the
+// middleware added to the commands.
+func (a *App) Use(mw ...Middleware) {
+	a.middleware = append(a.middleware, mw...)
+}
+
+// root returns a copy of the root command with the built-in flags, runner and
+// completion command added, so the command tree itself is never modified.
+func (a *App) root() *Command {
+	cmd := *a.Root
+	cmd.Flags = make([]Flag, 0, len(a.Root.Flags)+2)
+	cmd.Flags = append(cmd.Flags, a.Root.Flags...)
+	cmd.Flags = append(cmd.Flags, versionFlag)
+	if a.hasDryRunFlag() {
+		cmd.Flags = append(cmd.Flags, dryRunFlag)
+	}
+	if cmd.Run == nil {
+		cmd.Run = a.versionRunner
+	}
+	// Positional arguments of a root without sub-commands aren't shadowed
+	if cmd.subCommand(completionCommand.Name) == nil && (cmd.hasSubCommands() || !cmd.hasArgs()) {
+		cmd.Commands = append(cmd.Commands[:len(cmd.Commands):len(cmd.Commands)], a.completion(&cmd))
+	}
+	return &cmd
+}
+
+func (a *App) versionRunner(_ context.Context, params Params) error {
+	if params[versionFlag.Name] == true {
+		fmt.Fprintln(a.stdout(), a.version())
+	}
+
+	return nil
+}
+
+func (a *App) version() string {
+	if a.Version != "" {
+		return a.Version
+	}
+	if a.Root.Version != "" {
+		return a.Root.Version
+	}
+	return defaultVersion
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Out != nil {
+		return a.Out
+	}
+	return os.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Err != nil {
+		return a.Err
+	}
+	return os.Stderr
+}
+
+func (a *App) lookupEnv(key string) (string, bool) {
+	if a.LookupEnv != nil {
+		return a.LookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+func (a *App) isHelp(arg string) bool {
+	helpFlags := a.HelpFlags
+	if helpFlags == nil {
+		helpFlags = defaultHelpFlags
+	}
+	for _, flag := range helpFlags {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestRun_ShouldUseOSArgs(t *testing.T) {
+	var got cli.Params
+	cmd := cli.Command{Commands: []cli.Command{{
+		Name:  "exec",
+		Flags: []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		Run: func(_ context.Context, params cli.Params) error {
+			got = params
+			return nil
+		},
+	}}}
+	os.Args = osArgs([]string{"exec", "-u", "joe"})
+	if err := cli.Run(ctx, &cmd); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if got["user"] != "joe" {
+		t.Errorf("expected user = joe, got = %v", got["user"])
+	}
+}
+
+func TestRun_ShouldUseDefaultStreams(t *testing.T) {
+	out := bytes.Buffer{}
+	cli.Out = &out
+	defer func() { cli.Out = os.Stdout }()
+	os.Args = osArgs([]string{"--version"})
+	if err := cli.Run(ctx, &cli.Command{Version: "1.2.3"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if out.String() != "1.2.3\n" {
+		t.Errorf("expected version 1.2.3, got = %q", out.String())
+	}
+}
+
+func TestApp_ShouldNotModifyRoot(t *testing.T) {
+	cmd := cli.Command{Flags: []cli.Flag{{Name: "flag1"}}}
+	app := cli.NewApp(&cmd)
+	app.Out = &bytes.Buffer{}
+	for i := 0; i < 2; i++ {
+		if err := app.Run(ctx, []string{"--version"}); err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+	}
+	if len(cmd.Flags) != 1 {
+		t.Errorf("expected 1 flag, got = %+v", cmd.Flags)
+	}
+	if cmd.Run != nil {
+		t.Error("Root runner has been replaced")
+	}
+}
+
+func TestApp_ShouldRunInParallel(t *testing.T) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out := bytes.Buffer{}
+			app := cli.NewApp(&cli.Command{})
+			app.Version = fmt.Sprint(i)
+			app.Out = &out
+			if err := app.Run(ctx, []string{"-v"}); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if out.String() != fmt.Sprintln(i) {
+				t.Errorf("expected version %d, got = %s", i, out.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestApp_ShouldProvideAppInContext(t *testing.T) {
+	var got *cli.App
+	cmd := cli.Command{Commands: []cli.Command{{
+		Name: "exec",
+		Run: func(ctx context.Context, _ cli.Params) error {
+			got = cli.AppFromContext(ctx)
+			return nil
+		},
+	}}}
+	app := cli.NewApp(&cmd)
+	if err := app.Run(ctx, []string{"exec"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if got != app {
+		t.Errorf("expected app = %p, got = %p", app, got)
+	}
+	if cli.AppFromContext(ctx) != nil {
+		t.Error("expected no app in background context")
+	}
+}
+
+func TestApp_ShouldUseCustomHelpFlags(t *testing.T) {
+	cmd := cli.Command{Name: "cmd"}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	app.HelpFlags = []string{"-?"}
+	if err := app.Run(ctx, []string{"-?"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("Help not printed")
+	}
+}
+
+func TestApp_ShouldWrapRunWithMiddleware(t *testing.T) {
+	calls := make([]string, 0)
+	middleware := func(name string) cli.Middleware {
+		return func(next cli.Runner) cli.Runner {
+			return func(ctx context.Context, params cli.Params) error {
+				calls = append(calls, name+".before")
+				err := next(ctx, params)
+				calls = append(calls, name+".after")
+				return err
+			}
+		}
+	}
+	cmd := cli.Command{Name: "cmd", Run: func(_ context.Context, _ cli.Params) error {
+		calls = append(calls, "run")
+		return nil
+	}}
+	cmd.Use(middleware("cmd"))
+	root := cli.Command{Name: "app", Commands: []cli.Command{cmd}}
+	root.Use(middleware("root1"), middleware("root2"))
+	app := cli.NewApp(&root)
+	app.Use(middleware("app"))
+	if err := app.Run(ctx, []string{"cmd"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expected := []string{
+		"app.before", "root1.before", "root2.before", "cmd.before", "run",
+		"cmd.after", "root2.after", "root1.after", "app.after",
+	}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Errorf("expected = %+v, got = %+v", expected, calls)
+	}
+}
+
+func TestApp_ShouldDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		dryRun     bool
+		policy     cli.DryRunPolicy
+		expected   string
+		shouldFail bool
+	}{
+		{name: "Run", args: []string{"cmd"}, dryRun: true, expected: "run"},
+		{name: "DryRun", args: []string{"cmd", "--dry-run"}, dryRun: true, expected: "dry-run true"},
+		{name: "Refuse", args: []string{"cmd", "--dry-run"}, shouldFail: true},
+		{name: "Marker", args: []string{"cmd", "--dry-run"}, policy: cli.DryRunMarker, expected: "run true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := ""
+			cmd := cli.Command{Name: "cmd", Run: func(ctx context.Context, _ cli.Params) error {
+				ran = "run"
+				if cli.IsDryRun(ctx) {
+					ran += " true"
+				}
+				return nil
+			}}
+			if tt.dryRun {
+				cmd.DryRun = func(ctx context.Context, _ cli.Params) error {
+					ran = "dry-run"
+					if cli.IsDryRun(ctx) {
+						ran += " true"
+					}
+					return nil
+				}
+			}
+			// Another command with a DryRun function enables the --dry-run flag
+			other := cli.Command{Name: "other", DryRun: func(_ context.Context, _ cli.Params) error { return nil }}
+			app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{cmd, other}})
+			app.DryRunPolicy = tt.policy
+			err := app.Run(ctx, tt.args)
+			if (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+			if err != nil && !errors.Is(err, cli.ErrDryRunUnsupported) {
+				t.Errorf("expected ErrDryRunUnsupported, got = %v", err)
+			}
+			if ran != tt.expected {
+				t.Errorf("expected = %q, got = %q", tt.expected, ran)
+			}
+		})
+	}
+}
+
+func TestApp_ShouldKeepOwnDryRunFlag(t *testing.T) {
+	ran := ""
+	deploy := cli.Command{
+		Name:  "deploy",
+		Flags: []cli.Flag{{Name: "dry-run", Description: "Only plan the deployment."}},
+		Run: func(_ context.Context, params cli.Params) error {
+			ran = fmt.Sprint("deploy ", params["dry-run"])
+			return nil
+		},
+		DryRun: func(_ context.Context, _ cli.Params) error { return errors.New("DryRun executed") },
+	}
+	migrate := cli.Command{
+		Name: "migrate",
+		Run:  func(_ context.Context, _ cli.Params) error { return errors.New("Run executed") },
+		DryRun: func(_ context.Context, params cli.Params) error {
+			ran = fmt.Sprint("migrate ", params["dry-run"])
+			return nil
+		},
+	}
+	app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{deploy, migrate}})
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"deploy", "--dry-run"}, expected: "deploy true"},
+		{args: []string{"migrate", "--dry-run"}, expected: "migrate <nil>"},
+	}
+	for _, tt := range tests {
+		ran = ""
+		if err := app.Run(ctx, tt.args); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if ran != tt.expected {
+			t.Errorf("expected = %q, got = %q", tt.expected, ran)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"deploy", "--help"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if count := strings.Count(buf.String(), "--dry-run"); count != 1 {
+		t.Errorf("expected --dry-run once in help, got:\n%s", buf.String())
+	}
+}
+
+func TestApp_ShouldOnlyAddDryRunFlagIfUsed(t *testing.T) {
+	tests := []struct {
+		name   string
+		dryRun cli.Runner
+		policy cli.DryRunPolicy
+		want   bool
+	}{
+		{name: "Unused"},
+		{name: "DryRun", dryRun: func(_ context.Context, _ cli.Params) error { return nil }, want: true},
+		{name: "Marker", policy: cli.DryRunMarker, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{{Name: "cmd", DryRun: tt.dryRun}}})
+			app.DryRunPolicy = tt.policy
+			app.Out = &buf
+			if err := app.Run(ctx, []string{"help"}); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if strings.Contains(buf.String(), "--dry-run") != tt.want {
+				t.Errorf("unexpected help output:\n%s", buf.String())
+			}
+		})
+	}
+}
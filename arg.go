@@ -1,6 +1,9 @@
 package cli
 
-import "fmt"
+import (
+	"reflect"
+	"strings"
+)
 
 // Arg is an positional argument passed to a command.
 type Arg struct {
@@ -9,19 +12,70 @@ type Arg struct {
 	Parser      ParserFunc  // Parser function to use (Default: StringParser)
 	Required    bool        // If true, the execution will fail if the argument is not passed
 	Default     interface{} // Default value
-	Vararg      bool        // If true, the argument has an undefined length and is of type []string
+	Vararg      bool        // If true, the argument takes multiple values, collected in a slice of the parsed type
+	Min         int         // Minimum number of values of a vararg
+	Max         int         // Maximum number of values of a vararg (Default: unlimited)
+	EnvVar      string      // Environment variable used if the argument isn't passed
+	Choices     []string    // If set, only these values are accepted
+	IgnoreCase  bool        // If true, the choices are matched case insensitive
 }
 
-func (a *Arg) parse(val string) interface{} {
-	parser := StringParser
+func (a *Arg) parse(val string) (interface{}, error) {
+	return a.parser()(val)
+}
+
+func (a *Arg) parser() ParserFunc {
 	if a.Parser != nil {
-		parser = a.Parser
+		return choiceParser(a.Parser, a.Choices, a.IgnoreCase)
+	}
+	return choiceParser(StringParser, a.Choices, a.IgnoreCase)
+}
+
+// parseValues parses the values of a vararg into a typed slice.
+func (a *Arg) parseValues(values []string) (collected interface{}, err error) {
+	for _, val := range values {
+		parsed, err := a.parse(val)
+		if err != nil {
+			return nil, err
+		}
+		collected = appendValue(collected, parsed)
+	}
+	return collected, nil
+}
+
+// parseValue parses a value read from the environment. The values of a
+// vararg are separated by whitespace.
+func (a *Arg) parseValue(val string) (interface{}, error) {
+	if a.Vararg {
+		return a.parseValues(strings.Fields(val))
 	}
+	return a.parse(val)
+}
 
-	parsed, err := parser(val)
-	if err != nil {
-		fmt.Fprintln(Out, err.Error())
+// defaultValue returns the default value of the argument. String defaults are
+// passed through the parser, as well as []string defaults of varargs.
+func (a *Arg) defaultValue() (interface{}, error) {
+	switch def := a.Default.(type) {
+	case string:
+		if a.Vararg {
+			return a.parseValues([]string{def})
+		}
+		return a.parse(def)
+	case []string:
+		if a.Vararg {
+			return a.parseValues(def)
+		}
 	}
+	return a.Default, nil
+}
 
-	return parsed
+// count returns the number of values of a vararg.
+func (a *Arg) count(val interface{}) int {
+	if val == nil {
+		return 0
+	}
+	if v := reflect.ValueOf(val); v.Kind() == reflect.Slice {
+		return v.Len()
+	}
+	return 1
 }
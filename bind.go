@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bindOptions adds the flags and arguments declared by the struct tags of
+// c.Options. Flags use the tag `cli:"name,short"`, positional arguments the
+// tag `arg:"name"`. Further tags are `desc`, `env`, `default`, `required`,
+// `choices` (comma separated) and `negatable`.
+func (c *Command) bindOptions() error {
+	if c.Options == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(c.Options)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("options of command %s must be a pointer to a struct, got %T", c.Name, c.Options)
+	}
+
+	flags, args, err := optionParams(v.Elem().Type())
+	if err != nil {
+		return fmt.Errorf("invalid options of command %s: %w", c.Name, err)
+	}
+	c.Flags = append(c.Flags[:len(c.Flags):len(c.Flags)], flags...)
+	c.Args = append(c.Args[:len(c.Args):len(c.Args)], args...)
+
+	return nil
+}
+
+func optionParams(t reflect.Type) (flags []Flag, args []Arg, err error) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			f, a, err := optionParams(field.Type)
+			if err != nil {
+				return nil, nil, err
+			}
+			flags, args = append(flags, f...), append(args, a...)
+
+			continue
+		}
+
+		name, isFlag := optionName(field)
+		if name == "" || !field.IsExported() {
+			continue
+		}
+
+		// Slices and maps are collected from repeated values
+		kind := field.Type.Kind()
+		valueType := field.Type
+		if kind == reflect.Slice || kind == reflect.Map {
+			if kind == reflect.Map && field.Type.Key().Kind() != reflect.String {
+				return nil, nil, fmt.Errorf("field %s: unsupported map key type %s", field.Name, field.Type.Key())
+			}
+			valueType = field.Type.Elem()
+		}
+		parser, err := typeParser(valueType)
+		if err != nil {
+			return nil, nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		required, _ := strconv.ParseBool(field.Tag.Get("required"))
+		var choices []string
+		if tag := field.Tag.Get("choices"); tag != "" {
+			choices = strings.Split(tag, ",")
+		}
+
+		if !isFlag {
+			arg := Arg{Name: name, Description: field.Tag.Get("desc"), Parser: parser,
+				Required: required, EnvVar: field.Tag.Get("env"), Choices: choices}
+			switch kind {
+			case reflect.Map:
+				return nil, nil, fmt.Errorf("field %s: arguments can't be maps", field.Name)
+			case reflect.Slice:
+				arg.Vararg = true
+			}
+			if str, ok := field.Tag.Lookup("default"); ok {
+				arg.Default = str
+				if arg.Vararg {
+					arg.Default = strings.Split(str, ",")
+				}
+				if arg.Default, err = arg.defaultValue(); err != nil {
+					return nil, nil, fmt.Errorf("field %s: invalid default: %w", field.Name, err)
+				}
+			}
+			args = append(args, arg)
+
+			continue
+		}
+
+		flag := Flag{Name: name, Description: field.Tag.Get("desc"), Parser: parser, HasValue: kind != reflect.Bool,
+			Required: required, EnvVar: field.Tag.Get("env"), Choices: choices,
+			Repeatable: kind == reflect.Slice, KeyValue: kind == reflect.Map}
+		_, flag.Short, _ = strings.Cut(field.Tag.Get("cli"), ",")
+		flag.Negatable, _ = strconv.ParseBool(field.Tag.Get("negatable"))
+		if flag.Repeatable || flag.KeyValue {
+			flag.Separator = ","
+		}
+		if str, ok := field.Tag.Lookup("default"); ok {
+			if flag.Default, err = flag.parseValue(str); err != nil {
+				return nil, nil, fmt.Errorf("field %s: invalid default: %w", field.Name, err)
+			}
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, args, nil
+}
+
+// optionName returns the name of the flag or argument declared by a field.
+// The name is empty if the field doesn't declare one.
+func optionName(field reflect.StructField) (name string, isFlag bool) {
+	if tag, ok := field.Tag.Lookup("cli"); ok && tag != "-" {
+		name, _, _ = strings.Cut(tag, ",")
+		return name, true
+	}
+	if tag, ok := field.Tag.Lookup("arg"); ok && tag != "-" {
+		return tag, false
+	}
+	return "", false
+}
+
+// fillOptions sets the fields of the struct pointed to by opts from params.
+// Bound fields without a param are reset, so values of a previous run don't
+// remain.
+func fillOptions(opts interface{}, params Params) error {
+	return fillStruct(reflect.ValueOf(opts).Elem(), params)
+}
+
+func fillStruct(v reflect.Value, params Params) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := fillStruct(v.Field(i), params); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name, _ := optionName(field)
+		if name == "" || !field.IsExported() {
+			continue
+		}
+		val, ok := params[name]
+		if !ok {
+			v.Field(i).Set(reflect.Zero(field.Type))
+			continue
+		}
+
+		rv := reflect.ValueOf(val)
+		switch {
+		case rv.Type().AssignableTo(field.Type):
+			v.Field(i).Set(rv)
+		case rv.Kind() == field.Type.Kind() && rv.Type().ConvertibleTo(field.Type):
+			v.Field(i).Set(rv.Convert(field.Type))
+		default:
+			return fmt.Errorf("param %q: %w: %T instead of %s", name, ErrParamType, val, field.Type)
+		}
+	}
+
+	return nil
+}
+
+// typeParser returns a parser producing values of type t.
+func typeParser(t reflect.Type) (ParserFunc, error) {
+	if t == durationType {
+		return DurationParser, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(val string) (interface{}, error) {
+			return reflect.ValueOf(val).Convert(t).Interface(), nil
+		}, nil
+	case reflect.Bool:
+		return func(val string) (interface{}, error) {
+			b, err := parseBool(val)
+			return reflect.ValueOf(b).Convert(t).Interface(), err
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(val string) (interface{}, error) {
+			i, err := strconv.ParseInt(val, 0, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetInt(i)
+			if err != nil {
+				return v.Interface(), &FormatError{"an integer, e.g. 42", err}
+			}
+			return v.Interface(), nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(val string) (interface{}, error) {
+			u, err := strconv.ParseUint(val, 0, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetUint(u)
+			if err != nil {
+				return v.Interface(), &FormatError{"a positive integer, e.g. 42", err}
+			}
+			return v.Interface(), nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(val string) (interface{}, error) {
+			f, err := strconv.ParseFloat(val, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetFloat(f)
+			if err != nil {
+				return v.Interface(), &FormatError{"a number, e.g. 4.2", err}
+			}
+			return v.Interface(), nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
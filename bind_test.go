@@ -0,0 +1,201 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joewhite86/cli"
+)
+
+type level string
+
+type commonOptions struct {
+	Verbose bool `cli:"verbose,v" desc:"Verbose output"`
+	Color   bool `cli:"color" negatable:"true" default:"true"`
+}
+
+type loginOptions struct {
+	commonOptions
+	User     string            `cli:"user,u" desc:"User name" env:"LOGIN_USER" default:"joe"`
+	Pass     string            `cli:"pass" required:"true"`
+	Level    level             `cli:"level"`
+	Output   string            `cli:"output" choices:"json,yaml" default:"json"`
+	Int8     int8              `cli:"int8"`
+	Int64    int64             `cli:"int64"`
+	Uint16   uint16            `cli:"uint16"`
+	Float32  float32           `cli:"float32"`
+	Float64  float64           `cli:"float64"`
+	Timeout  time.Duration     `cli:"timeout" default:"5s"`
+	Tags     []string          `cli:"tags"`
+	Ports    []int             `cli:"ports"`
+	Labels   map[string]string `cli:"labels"`
+	Host     string            `arg:"host" required:"true"`
+	Files    []string          `arg:"files"`
+	Ignored  string
+	Excluded string `cli:"-"`
+}
+
+func TestRun_ShouldBindOptions(t *testing.T) {
+	opts := loginOptions{}
+	ran := false
+	login := cli.Command{Name: "login", Options: &opts, Run: func(_ context.Context, params cli.Params) error {
+		ran = true
+		return nil
+	}}
+	cmd := cli.Command{Commands: []cli.Command{login}}
+	args := []string{
+		"login", "-v", "--no-color", "--pass", "secret", "--level", "debug", "--int8", "-8", "--int64", "64",
+		"--uint16", "16", "--float32", "3.2", "--float64", "6.4", "--tags", "a,b", "--ports", "80", "--ports", "443",
+		"--labels", "a=1,b=2", "localhost", "file1", "file2",
+	}
+	if err := cli.NewApp(&cmd).Run(ctx, args); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !ran {
+		t.Error("Handler not executed")
+	}
+
+	expected := loginOptions{
+		commonOptions: commonOptions{Verbose: true, Color: false},
+		User:          "joe",
+		Pass:          "secret",
+		Level:         "debug",
+		Output:        "json",
+		Int8:          -8,
+		Int64:         64,
+		Uint16:        16,
+		Float32:       3.2,
+		Float64:       6.4,
+		Timeout:       5 * time.Second,
+		Tags:          []string{"a", "b"},
+		Ports:         []int{80, 443},
+		Labels:        map[string]string{"a": "1", "b": "2"},
+		Host:          "localhost",
+		Files:         []string{"file1", "file2"},
+	}
+	if !reflect.DeepEqual(expected, opts) {
+		t.Errorf("expected = %+v, got = %+v", expected, opts)
+	}
+}
+
+func TestRun_ShouldBindTypedVarargs(t *testing.T) {
+	opts := struct {
+		Ports []uint16 `arg:"ports"`
+		Host  string   `arg:"host"`
+	}{}
+	cmd := cli.Command{Name: "scan", Options: &opts, Run: func(_ context.Context, _ cli.Params) error {
+		return nil
+	}}
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"80", "443", "localhost"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !reflect.DeepEqual([]uint16{80, 443}, opts.Ports) || opts.Host != "localhost" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+func TestRun_ShouldBindParentOptions(t *testing.T) {
+	opts := commonOptions{}
+	sub := cli.Command{Name: "sub", Run: func(_ context.Context, _ cli.Params) error {
+		return nil
+	}}
+	cmd := cli.Command{Name: "app", Options: &opts, Commands: []cli.Command{sub}}
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"--verbose", "sub"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !opts.Verbose || !opts.Color {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+func TestRun_ShouldResetOptions(t *testing.T) {
+	opts := struct {
+		User    string `cli:"user"`
+		Ignored string
+	}{Ignored: "kept"}
+	sub := cli.Command{Name: "sub", Options: &opts, Run: func(_ context.Context, _ cli.Params) error {
+		return nil
+	}}
+	app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{sub}})
+	if err := app.Run(ctx, []string{"sub", "--user", "joe"}); err != nil || opts.User != "joe" {
+		t.Fatalf("unexpected options %+v, error %v", opts, err)
+	}
+	if err := app.Run(ctx, []string{"sub"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if opts.User != "" || opts.Ignored != "kept" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+func TestRun_ShouldValidateBoundOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "RequiredFlag", args: []string{"login", "localhost"}},
+		{name: "RequiredArg", args: []string{"login", "--pass", "secret"}},
+		{name: "InvalidValue", args: []string{"login", "--pass", "secret", "--int8", "1000", "localhost"}},
+		{name: "InvalidChoice", args: []string{"login", "--pass", "secret", "--output", "xml", "localhost"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			login := cli.Command{Name: "login", Options: &loginOptions{}, Run: func(_ context.Context, _ cli.Params) error {
+				return nil
+			}}
+			cmd := cli.Command{Commands: []cli.Command{login}}
+			if err := cli.NewApp(&cmd).Run(ctx, tt.args); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRun_ShouldRejectInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options interface{}
+	}{
+		{name: "NoPointer", options: loginOptions{}},
+		{name: "UnsupportedType", options: &struct {
+			Chan chan int `cli:"chan"`
+		}{}},
+		{name: "InvalidDefault", options: &struct {
+			Int int `cli:"int" default:"test"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			login := cli.Command{Name: "login", Options: tt.options, Run: func(_ context.Context, _ cli.Params) error {
+				return nil
+			}}
+			cmd := cli.Command{Commands: []cli.Command{login}}
+			if err := cli.NewApp(&cmd).Run(ctx, []string{"login"}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRun_ShouldPrintHelpBoundOptions(t *testing.T) {
+	login := cli.Command{Name: "login", Options: &loginOptions{}}
+	cmd := cli.Command{Commands: []cli.Command{login}}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"login", "--help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for _, want := range []string{"-u,--user=joe:     User name [$LOGIN_USER]", "-v,--verbose:     Verbose output", "<host>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Output doesn't contain %q", want)
+		}
+	}
+	if len(login.Flags) != 0 {
+		t.Error("Command tree has been modified")
+	}
+}
@@ -2,14 +2,22 @@ package cli
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 var defaultVersion = "0.1.0"
-var versionFlag = Flag{Short: "v", Name: "version", Description: "Print the version."}
+var versionFlag = Flag{Short: "v", Name: "version", Description: "Print the version.", builtin: true}
+
+// Out is the standard output of apps created by NewApp.
+//
+// Deprecated: Set App.Out instead.
 var Out io.Writer = os.Stdout
+
+// Err is the error output of apps created by NewApp.
+//
+// Deprecated: Set App.Err instead.
 var Err io.Writer = os.Stderr
 
 // A Runner function can be defined as Command.Run or Command.DryRun function.
@@ -17,21 +25,13 @@ var Err io.Writer = os.Stderr
 // The params map will contain a field "_args" with the original argument list.
 type Runner func(ctx context.Context, params Params) error
 
-func rootRunner(_ context.Context, params Params) error {
-	if params[versionFlag.Name] != nil {
-		if root.Version != "" {
-			fmt.Fprintln(Out, root.Version)
-		} else {
-			fmt.Fprintln(Out, defaultVersion)
-		}
-	}
-
-	return nil
-}
+// A Middleware wraps a Runner, e.g. to add logging or a timeout to commands.
+type Middleware func(next Runner) Runner
 
 // A Command defines a command, or sub-command that can be run by the user.
 type Command struct {
 	Name     string    // Command name used in help text and the params map
+	Aliases  []string  // Alternative names of the command
 	Group    string    // Group name used to group commands inside help
 	Short    string    // Short description, shown in the containing commands help
 	Long     string    // Long description, shown in the help text
@@ -39,15 +39,110 @@ type Command struct {
 	Flags    []Flag    // Flags for this command
 	Commands []Command // Contains a list of sub-commands
 	Run      Runner    // The command handler to execute
+	PreRun   Runner    // Executed before Run
+	PostRun  Runner    // Executed after Run, even if it fails
+	DryRun   Runner    // Executed instead of Run if --dry-run is passed
 	Version  string    // Version used in the root command to print the cli's version
 
-	showHelp bool
+	// If true, flag parsing stops at the first positional argument, which is
+	// useful for commands wrapping other programs
+	DisableInterspersed bool
+
+	// Pointer to a struct declaring additional flags and arguments with struct
+	// tags. The struct is filled with the parsed params before Run is called.
+	Options interface{}
+
+	MinArgs     int          // Minimum number of positional arguments
+	MaxArgs     int          // Maximum number of positional arguments, or NoArgs (Default: unlimited)
+	Constraints []Constraint // Constraints on the combination of flags
+
+	// Validates the params once they are parsed, before Run is called.
+	// Errors are returned as ValidationError.
+	Validate func(params Params) error
+
+	// Executed before the PreRun hook of this command and of its sub-commands,
+	// starting with the root command
+	PersistentPreRun Runner
+	// Executed after the PostRun hook of this command and of its sub-commands,
+	// ending with the root command
+	PersistentPostRun Runner
+
+	showHelp  bool
+	inherited []Flag   // Persistent flags of the parent commands
+	parent    *Command // Parent command on the resolved path
+
+	middleware []Middleware
 }
 
 func (c *Command) Runnable() bool {
 	return c.Run != nil
 }
 
+// Use adds middleware wrapping the Run function of the command and of its
+// sub-commands. Middleware of parent commands wraps the one of sub-commands.
+func (c *Command) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// execute runs the command with its hooks. The persistent pre hooks of the
+// commands on the path run root first, the post hooks in reverse order. If a
+// hook or Run fails, the remaining pre hooks and Run are skipped, but all post
+// hooks run with the error available via RunErrorFromContext. The first error
+// is returned. The run function, which is Run or DryRun, is wrapped by the
+// given middleware, followed by the middleware of the commands on the path.
+func (c *Command) execute(ctx context.Context, params Params, run Runner, middleware []Middleware) error {
+	path := c.path()
+	for _, cmd := range path {
+		middleware = append(middleware[:len(middleware):len(middleware)], cmd.middleware...)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		run = middleware[i](run)
+	}
+
+	var err error
+	pre := make([]Runner, 0, len(path)+2)
+	for _, cmd := range path {
+		pre = append(pre, cmd.PersistentPreRun)
+	}
+	for _, hook := range append(pre, c.PreRun, run) {
+		if hook != nil && err == nil {
+			err = hook(ctx, params)
+		}
+	}
+
+	post := []Runner{c.PostRun}
+	for i := len(path) - 1; i >= 0; i-- {
+		post = append(post, path[i].PersistentPostRun)
+	}
+	for _, hook := range post {
+		if hook == nil {
+			continue
+		}
+		if hookErr := hook(context.WithValue(ctx, runErrorKey{}, err), params); err == nil {
+			err = hookErr
+		}
+	}
+
+	return err
+}
+
+// path returns the commands on the resolved path, starting with the root.
+func (c *Command) path() []*Command {
+	if c.parent == nil {
+		return []*Command{c}
+	}
+	return append(c.parent.path(), c)
+}
+
+type runErrorKey struct{}
+
+// RunErrorFromContext returns the error of the failed Run function or hook,
+// or nil. It's available in post hooks.
+func RunErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(runErrorKey{}).(error)
+	return err
+}
+
 func (c *Command) hasFlags() bool {
 	return c.Flags != nil && len(c.Flags) > 0
 }
@@ -59,3 +154,131 @@ func (c *Command) hasArgs() bool {
 func (c *Command) hasSubCommands() bool {
 	return c.Commands != nil && len(c.Commands) > 0
 }
+
+// names returns the name of the command followed by its aliases.
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// subCommand returns a copy of the sub-command with the given name or alias,
+// or nil. The copy inherits the persistent flags of c.
+func (c *Command) subCommand(name string) *Command {
+	for _, cmd := range c.Commands {
+		if contains(cmd.names(), name) {
+			clone := cmd
+			clone.inherited = c.persistentFlags()
+			clone.parent = c
+			return &clone
+		}
+	}
+	return nil
+}
+
+// subCommandsWithPrefix returns the names of the sub-commands whose name or
+// one of its aliases starts with prefix.
+func (c *Command) subCommandsWithPrefix(prefix string) []string {
+	names := make([]string, 0)
+	for _, cmd := range c.Commands {
+		for _, name := range cmd.names() {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, cmd.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// walkCommands calls fn for cmd and all of its sub-commands.
+func walkCommands(cmd *Command, fn func(cmd *Command)) {
+	fn(cmd)
+	for i := range cmd.Commands {
+		walkCommands(&cmd.Commands[i], fn)
+	}
+}
+
+// persistentFlags returns the flags passed on to sub-commands.
+func (c *Command) persistentFlags() []Flag {
+	flags := c.inheritedFlags()
+	for _, flag := range c.Flags {
+		if flag.Persistent {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// allFlags returns the flags of the command followed by the inherited flags.
+func (c *Command) allFlags() []Flag {
+	flags := make([]Flag, 0, len(c.Flags)+len(c.inherited))
+	flags = append(flags, c.Flags...)
+	return append(flags, c.inheritedFlags()...)
+}
+
+// inheritedFlags returns the inherited flags that aren't shadowed by a flag of
+// the command with the same name.
+func (c *Command) inheritedFlags() []Flag {
+	flags := make([]Flag, 0, len(c.inherited))
+	for _, flag := range c.inherited {
+		shadowed := false
+		for _, own := range c.Flags {
+			shadowed = shadowed || own.Name == flag.Name
+		}
+		if !shadowed {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// hasFlag reports whether the command or its parents define a flag with the
+// given name.
+func (c *Command) hasFlag(name string) bool {
+	for _, flag := range c.allFlags() {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flag returns the flag matching the given argument and its attached value,
+// which is "false" for "--no-<name>". Exact matches take precedence over
+// attached values. Returns nil if no flag matches.
+func (c *Command) flag(arg string) (flag *Flag, value string, attached bool) {
+	flags := c.allFlags()
+	for i := range flags {
+		if flags[i].matches(arg) {
+			return &flags[i], "", false
+		}
+		if flags[i].negates(arg) {
+			return &flags[i], "false", true
+		}
+	}
+	for i := range flags {
+		if value, ok := flags[i].matchesAttached(arg); ok {
+			return &flags[i], value, true
+		}
+	}
+	return nil, "", false
+}
+
+// shortFlag returns the flag whose short name prefixes the given cluster, or nil.
+func (c *Command) shortFlag(cluster string) *Flag {
+	flags := c.allFlags()
+	for i := range flags {
+		if flags[i].Short != "" && strings.HasPrefix(cluster, flags[i].Short) {
+			return &flags[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+# bash completion for {{ .Program }}, generated by "{{ .Program }} completion bash"
+
+_{{ .Function }}() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local cmd_path={{ quote .Program }} i
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${cmd_path} ${COMP_WORDS[i]}" in
+{{- range .Nodes }}{{ $node := . }}{{ range .Commands }}
+            {{ quote (print $node.Path " " .Name) }}) cmd_path={{ quote .Path }} ;;
+{{- end }}{{ end }}
+        esac
+    done
+
+    case "${cmd_path} ${prev}" in
+{{- range .Nodes }}{{ $node := . }}{{ range .Flags }}{{ if .HasValue }}
+        {{ range $i, $name := .Names }}{{ if $i }}|{{ end }}{{ quote (print $node.Path " " $name) }}{{ end }})
+            COMPREPLY=($(compgen -W {{ quote (join .Choices " ") }} -- "${cur}"))
+            return
+            ;;
+{{- end }}{{ end }}{{ end }}
+    esac
+
+    case "${cmd_path}" in
+{{- range .Nodes }}
+        {{ quote .Path }})
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W {{ quote (join .FlagWords " ") }} -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -W {{ quote (join .ArgWords " ") }} -- "${cur}"))
+            fi
+            ;;
+{{- end }}
+    esac
+}
+
+complete -o default -F _{{ .Function }} {{ quote .Program }}
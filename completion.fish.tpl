@@ -0,0 +1,34 @@
+# fish completion for {{ .Program }}, generated by "{{ .Program }} completion fish"
+
+function __{{ .Function }}_path
+    set -l cmd_path {{ quote .Program }}
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    for word in $tokens
+        switch "$cmd_path $word"
+{{- range .Nodes }}{{ $node := . }}{{ range .Commands }}
+            case {{ quote (print $node.Path " " .Name) }}
+                set cmd_path {{ quote .Path }}
+{{- end }}{{ end }}
+        end
+    end
+    echo $cmd_path
+end
+
+function __{{ .Function }}_using_path
+    test (__{{ .Function }}_path) = "$argv[1]"
+end
+{{ range .Nodes }}{{ $cond := quote (print "__" $.Function "_using_path " (quote .Path)) }}
+{{- range .Commands }}
+complete -c {{ quote $.Program }} -n {{ $cond }} -f -a {{ quote .Name }}{{ with .Description }} -d {{ quote . }}{{ end }}
+{{- end }}
+{{- range .Flags }}
+complete -c {{ quote $.Program }} -n {{ $cond }} -l {{ quote .Long }}{{ with .Short }} -{{ if gt (len .) 1 }}o{{ else }}s{{ end }} {{ quote . }}{{ end }}{{ if .Choices }} -x -a {{ quote (join .Choices " ") }}{{ else if .HasValue }} -r{{ end }}{{ with .Description }} -d {{ quote . }}{{ end }}
+{{- if .Negatable }}
+complete -c {{ quote $.Program }} -n {{ $cond }} -l {{ quote (print "no-" .Long) }}{{ with .Description }} -d {{ quote . }}{{ end }}
+{{- end }}
+{{- end }}
+{{- range .Args }}
+complete -c {{ quote $.Program }} -n {{ $cond }} -f -a {{ quote .Value }}{{ with .Description }} -d {{ quote . }}{{ end }}
+{{- end }}
+{{ end -}}
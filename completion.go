@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed completion.bash.tpl
+	bashTemplate string
+	//go:embed completion.zsh.tpl
+	zshTemplate string
+	//go:embed completion.fish.tpl
+	fishTemplate string
+	//go:embed completion.ps1.tpl
+	powershellTemplate string
+)
+
+var completionTemplates = map[string]string{
+	"bash":       bashTemplate,
+	"zsh":        zshTemplate,
+	"fish":       fishTemplate,
+	"powershell": powershellTemplate,
+}
+
+var completionCommand = Command{
+	Name:  "completion",
+	Short: "Print the shell completion script.",
+	Args: []Arg{{
+		Name: "shell", Description: "Shell to complete in.", Required: true,
+		Choices: []string{"bash", "zsh", "fish", "powershell"},
+	}},
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// completion holds the words to complete for each command of the tree.
+type completion struct {
+	Program  string           // Name of the program
+	Function string           // Program name usable as shell function name
+	Nodes    []completionNode // Commands of the tree, starting with the root
+}
+
+type completionNode struct {
+	Path     string                 // Names of the commands leading to the node, e.g. "app login"
+	Commands []completionSubCommand // Sub-commands, with an entry for each alias
+	Flags    []completionFlag
+	Args     []completionChoice // Choices of the positional arguments
+}
+
+type completionSubCommand struct {
+	Name        string // Name or alias of the sub-command
+	Description string
+	Path        string // Path of the sub-command
+}
+
+type completionFlag struct {
+	Long        string
+	Short       string
+	Description string
+	Negatable   bool
+	HasValue    bool
+	Choices     []string
+}
+
+type completionChoice struct {
+	Value       string
+	Description string
+}
+
+// completion returns the built-in completion command, which prints the
+// completion script of the root command for the given shell.
+func (a *App) completion(root *Command) Command {
+	completion := completionCommand
+	completion.Run = func(_ context.Context, params Params) error {
+		return a.writeCompletion(a.stdout(), root, params["shell"].(string))
+	}
+	return completion
+}
+
+// writeCompletion writes the completion script for the shell to w.
+func (a *App) writeCompletion(w io.Writer, root *Command, shell string) error {
+	program := root.Name
+	if program == "" {
+		program = filepath.Base(os.Args[0])
+	}
+
+	data := completion{
+		Program:  program,
+		Function: nonIdentifier.ReplaceAllString(program, "_"),
+		Nodes:    completionNodes(root, program),
+	}
+
+	tmpl, err := template.New(shell).Funcs(completionFuncs(shell)).Parse(completionTemplates[shell])
+	if err != nil {
+		return fmt.Errorf("completion template %s: %w", shell, err)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// completionNodes walks the command tree and returns a node for every command.
+func completionNodes(cmd *Command, path string) []completionNode {
+	// Invalid options are reported by lint
+	_ = cmd.bindOptions()
+
+	node := completionNode{Path: path}
+	for _, sub := range cmd.Commands {
+		for _, name := range sub.names() {
+			node.Commands = append(node.Commands, completionSubCommand{name, sub.Short, path + " " + sub.Name})
+		}
+	}
+	for _, flag := range cmd.allFlags() {
+		node.Flags = append(node.Flags, completionFlag{
+			Long: flag.Name, Short: flag.Short, Description: flag.Description,
+			Negatable: flag.Negatable && flag.isBool(), HasValue: flag.HasValue, Choices: flag.Choices,
+		})
+	}
+	for _, arg := range cmd.Args {
+		for _, choice := range arg.Choices {
+			node.Args = append(node.Args, completionChoice{choice, arg.Description})
+		}
+	}
+
+	nodes := []completionNode{node}
+	for _, sub := range cmd.Commands {
+		nodes = append(nodes, completionNodes(cmd.subCommand(sub.Name), path+" "+sub.Name)...)
+	}
+	return nodes
+}
+
+// Names returns the command line forms of the flag, e.g. "--user" and "-u".
+func (f completionFlag) Names() []string {
+	names := []string{"--" + f.Long}
+	if f.Short != "" {
+		names = append(names, "-"+f.Short)
+	}
+	return names
+}
+
+// Words returns the names of the flag, including "--no-<name>" if it's
+// negatable.
+func (f completionFlag) Words() []string {
+	if f.Negatable {
+		return append(f.Names(), "--no-"+f.Long)
+	}
+	return f.Names()
+}
+
+// FlagWords returns the words of all flags of the node.
+func (n completionNode) FlagWords() []string {
+	words := make([]string, 0, len(n.Flags))
+	for _, flag := range n.Flags {
+		words = append(words, flag.Words()...)
+	}
+	return words
+}
+
+// ArgWords returns the sub-commands and argument choices of the node.
+func (n completionNode) ArgWords() []string {
+	words := make([]string, 0, len(n.Commands)+len(n.Args))
+	for _, cmd := range n.Commands {
+		words = append(words, cmd.Name)
+	}
+	for _, arg := range n.Args {
+		words = append(words, arg.Value)
+	}
+	return words
+}
+
+// completionFuncs returns the template functions for the shell. The quote
+// function returns a single quoted string literal of the shell.
+func completionFuncs(shell string) template.FuncMap {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	switch shell {
+	case "fish":
+		quote = func(s string) string {
+			return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+		}
+	case "powershell":
+		quote = func(s string) string {
+			return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+	}
+
+	return template.FuncMap{
+		"quote": quote,
+		"quoteAll": func(values []string, sep string) string {
+			quoted := make([]string, len(values))
+			for i, val := range values {
+				quoted[i] = quote(val)
+			}
+			return strings.Join(quoted, sep)
+		},
+		"join": strings.Join,
+		// describe returns a zsh _describe entry, "word:description"
+		"describe": func(word, description string) string {
+			word = strings.ReplaceAll(word, ":", `\:`)
+			if description == "" {
+				return quote(word)
+			}
+			return quote(word + ":" + description)
+		},
+	}
+}
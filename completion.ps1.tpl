@@ -0,0 +1,37 @@
+# powershell completion for {{ .Program }}, generated by "{{ .Program }} completion powershell"
+
+Register-ArgumentCompleter -Native -CommandName {{ quote .Program }} -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $path = {{ quote .Program }}
+    $prev = ''
+    $elements = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition })
+    foreach ($element in $elements | Select-Object -Skip 1) {
+        $prev = $element.ToString()
+        $path = switch -CaseSensitive -Exact ("$path $prev") {
+{{- range .Nodes }}{{ $node := . }}{{ range .Commands }}
+            {{ quote (print $node.Path " " .Name) }} { {{ quote .Path }} }
+{{- end }}{{ end }}
+            default { $path }
+        }
+    }
+
+    $candidates = switch -CaseSensitive -Exact ("$path $prev") {
+{{- range .Nodes }}{{ $node := . }}{{ range .Flags }}{{ if .HasValue }}{{ $flag := . }}{{ range .Names }}
+        {{ quote (print $node.Path " " .) }} { {{ if $flag.Choices }}@({{ quoteAll $flag.Choices ", " }}){{ else }}return{{ end }} }
+{{- end }}{{ end }}{{ end }}{{ end }}
+        default {
+            switch -CaseSensitive -Exact ($path) {
+{{- range .Nodes }}
+                {{ quote .Path }} {
+                    if ($wordToComplete.StartsWith('-')) { @({{ quoteAll .FlagWords ", " }}) } else { @({{ quoteAll .ArgWords ", " }}) }
+                }
+{{- end }}
+            }
+        }
+    }
+
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
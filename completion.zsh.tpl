@@ -0,0 +1,46 @@
+#compdef {{ .Program }}
+
+# zsh completion for {{ .Program }}, generated by "{{ .Program }} completion zsh"
+
+_{{ .Function }}() {
+    local cur="${words[CURRENT]}" prev="${words[CURRENT-1]}"
+    local cmd_path={{ quote .Program }} i
+    local -a candidates
+
+    for ((i = 2; i < CURRENT; i++)); do
+        case "${cmd_path} ${words[i]}" in
+{{- range .Nodes }}{{ $node := . }}{{ range .Commands }}
+            {{ quote (print $node.Path " " .Name) }}) cmd_path={{ quote .Path }} ;;
+{{- end }}{{ end }}
+        esac
+    done
+
+    case "${cmd_path} ${prev}" in
+{{- range .Nodes }}{{ $node := . }}{{ range .Flags }}{{ if .HasValue }}
+        {{ range $i, $name := .Names }}{{ if $i }}|{{ end }}{{ quote (print $node.Path " " $name) }}{{ end }})
+            {{ if .Choices }}compadd -- {{ quoteAll .Choices " " }}{{ else }}_files{{ end }}
+            return
+            ;;
+{{- end }}{{ end }}{{ end }}
+    esac
+
+    case "${cmd_path}" in
+{{- range .Nodes }}
+        {{ quote .Path }})
+            if [[ "${cur}" == -* ]]; then
+                candidates=({{ range $i, $flag := .Flags }}{{ range $j, $word := .Words }}{{ if or $i $j }} {{ end }}{{ describe $word $flag.Description }}{{ end }}{{ end }})
+                _describe -t flags 'flag' candidates
+            else
+                candidates=({{ range $i, $cmd := .Commands }}{{ if $i }} {{ end }}{{ describe .Name .Description }}{{ end }}{{ if and .Commands .Args }} {{ end }}{{ range $i, $arg := .Args }}{{ if $i }} {{ end }}{{ describe .Value .Description }}{{ end }})
+                _describe -t commands 'command' candidates
+            fi
+            ;;
+{{- end }}
+    esac
+}
+
+if [ "${funcstack[1]}" = "_{{ .Function }}" ]; then
+    _{{ .Function }} "$@"
+else
+    compdef _{{ .Function }} {{ quote .Program }}
+fi
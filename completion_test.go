@@ -0,0 +1,126 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func completionTree() *cli.Command {
+	login := cli.Command{
+		Name: "login", Aliases: []string{"signin"}, Short: "Login to the server.",
+		Flags: []cli.Flag{
+			{Name: "user", Short: "u", HasValue: true, Description: "User name"},
+			{Name: "output", Short: "o", HasValue: true, Choices: []string{"json", "yaml"}, Description: "Output format"},
+			{Name: "color", Negatable: true, Description: "Colored output"},
+		},
+		Args: []cli.Arg{{Name: "host", Description: "Server: local or remote", Choices: []string{"local", "remote"}}},
+	}
+	logout := cli.Command{Name: "logout", Short: "Log out, it's 'done'."}
+	return &cli.Command{
+		Name:     "app",
+		Commands: []cli.Command{login, logout},
+		Flags:    []cli.Flag{{Name: "debug", Short: "d", Persistent: true, Description: "Debug output"}},
+	}
+}
+
+func TestApp_ShouldPrintCompletion(t *testing.T) {
+	tests := []struct {
+		shell  string
+		golden string
+	}{
+		{shell: "bash", golden: "completion.bash"},
+		{shell: "zsh", golden: "completion.zsh"},
+		{shell: "fish", golden: "completion.fish"},
+		{shell: "powershell", golden: "completion.ps1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			app := cli.NewApp(completionTree())
+			app.Out = &buf
+			if err := app.Run(ctx, []string{"completion", tt.shell}); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil { // nolint:gosec
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(expected) {
+				t.Errorf("Output doesn't match %s, run the tests with -update to update it:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestApp_ShouldResolveCompletionCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		prefix bool
+	}{
+		{name: "PersistentFlag", args: []string{"--debug", "completion", "bash"}},
+		{name: "Prefix", args: []string{"compl", "bash"}, prefix: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			app := cli.NewApp(completionTree())
+			app.Out = &buf
+			app.PrefixMatching = tt.prefix
+			if err := app.Run(ctx, tt.args); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !strings.HasPrefix(buf.String(), "# bash completion for app") {
+				t.Errorf("unexpected output:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestApp_ShouldPrintHelpCompletionCommand(t *testing.T) {
+	buf := bytes.Buffer{}
+	app := cli.NewApp(completionTree())
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "  completion       Print the shell completion script.") {
+		t.Errorf("Output doesn't list the completion command:\n%s", buf.String())
+	}
+}
+
+func TestApp_ShouldRejectUnknownCompletionShell(t *testing.T) {
+	err := cli.NewApp(completionTree()).Run(ctx, []string{"completion", "tcsh"})
+	var invalidErr *cli.InvalidValueError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("expected an InvalidValueError, got = %v", err)
+	}
+}
+
+func TestApp_ShouldPreferCompletionCommand(t *testing.T) {
+	ran := false
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{{Name: "completion", Run: func(_ context.Context, _ cli.Params) error {
+		ran = true
+		return nil
+	}}}}
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"completion"}); err != nil || !ran {
+		t.Errorf("Command not executed, error %v", err)
+	}
+}
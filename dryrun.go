@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDryRunUnsupported is returned if --dry-run is passed to a command
+// without a DryRun function, unless the App uses DryRunMarker.
+var ErrDryRunUnsupported = errors.New("dry run not supported")
+
+// The built-in --dry-run flag uses an internal params key, so it can't be
+// confused with a dry-run flag declared by a command.
+var dryRunFlag = Flag{
+	Name: "dry-run", Description: "Show what would be done, without doing it.", Persistent: true, builtin: true,
+	key: "_dry_run",
+}
+
+// DryRunPolicy defines how an App handles --dry-run for commands without a
+// DryRun function.
+type DryRunPolicy int
+
+const (
+	// DryRunRefuse fails with ErrDryRunUnsupported.
+	DryRunRefuse DryRunPolicy = iota
+	// DryRunMarker calls Run, which checks IsDryRun to skip side effects.
+	DryRunMarker
+)
+
+type dryRunKey struct{}
+
+// IsDryRun reports whether --dry-run has been passed to the running command.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// runner returns the function to run for the command. With --dry-run it's the
+// DryRun function, or Run if the DryRunPolicy allows it. The context is marked
+// accordingly.
+func (a *App) runner(ctx context.Context, cmd *Command, params Params) (context.Context, Runner, error) {
+	if params[dryRunFlag.key] != true {
+		return ctx, cmd.Run, nil
+	}
+
+	ctx = context.WithValue(ctx, dryRunKey{}, true)
+	switch {
+	case cmd.DryRun != nil:
+		return ctx, cmd.DryRun, nil
+	case a.DryRunPolicy == DryRunMarker:
+		return ctx, cmd.Run, nil
+	}
+	names := make([]string, 0)
+	for _, c := range cmd.path() {
+		names = append(names, c.Name)
+	}
+	return nil, nil, fmt.Errorf("command %q: %w", strings.TrimSpace(strings.Join(names, " ")), ErrDryRunUnsupported)
+}
+
+// hasDryRunFlag reports whether the built-in --dry-run flag is added to the
+// root command. That's the case if a command defines a DryRun function or the
+// App uses DryRunMarker. Commands declaring their own dry-run flag shadow it.
+func (a *App) hasDryRunFlag() bool {
+	used := a.DryRunPolicy == DryRunMarker
+	walkCommands(a.Root, func(cmd *Command) {
+		used = used || cmd.DryRun != nil
+	})
+	return used
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError holds the details shared by all errors caused by invalid command
+// line input.
+type ParseError struct {
+	Token     string // The offending command line token, empty if a value is missing
+	Path      string // Path of the parsed command, e.g. "app sub"
+	UsageLine string // Usage line of the parsed command
+}
+
+// Details returns the parse details of a UsageError.
+func (e *ParseError) Details() *ParseError {
+	return e
+}
+
+// UsageError is implemented by all errors caused by invalid command line input.
+// Use errors.As to retrieve it from an error returned by Run.
+type UsageError interface {
+	error
+	Details() *ParseError
+}
+
+// UnknownFlagError is returned if a flag isn't defined for the command.
+type UnknownFlagError struct {
+	ParseError
+	Suggestion string // Most similar flag, empty if there's none
+}
+
+func (e *UnknownFlagError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown flag %q, did you mean %q?", e.Token, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown flag %q", e.Token)
+}
+
+// UnknownCommandError is returned if an argument doesn't match any sub-command.
+type UnknownCommandError struct {
+	ParseError
+	Suggestion string // Most similar sub-command, empty if there's none
+}
+
+func (e *UnknownCommandError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown command %q, did you mean %q?", e.Token, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown command %q", e.Token)
+}
+
+// AmbiguousError is returned if a prefix matches several sub-commands or
+// flags. Prefixes are only matched if App.PrefixMatching is enabled.
+type AmbiguousError struct {
+	ParseError
+	Candidates []string // Names of the matching sub-commands or flags
+	Flag       bool     // True if the prefix refers to a flag
+}
+
+func (e *AmbiguousError) Error() string {
+	kind := "command"
+	if e.Flag {
+		kind = "flag"
+	}
+	return fmt.Sprintf("ambiguous %s %q, could be %s", kind, e.Token, strings.Join(e.Candidates, ", "))
+}
+
+// UnexpectedArgumentError is returned if more positional arguments are passed
+// than the command defines.
+type UnexpectedArgumentError struct {
+	ParseError
+}
+
+func (e *UnexpectedArgumentError) Error() string {
+	return fmt.Sprintf("unexpected argument %q", e.Token)
+}
+
+// MissingValueError is returned if a flag with a value is the last argument.
+type MissingValueError struct {
+	ParseError
+}
+
+func (e *MissingValueError) Error() string {
+	return fmt.Sprintf("flag %s requires a value", e.Token)
+}
+
+// InvalidValueError is returned if the ParserFunc of a flag or argument fails.
+type InvalidValueError struct {
+	ParseError
+	Name string // Name of the flag ("--name") or argument ("<name>")
+	Err  error  // Error returned by the ParserFunc
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid value %q for %s: %v", e.Token, e.Name, e.Err)
+}
+
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// MissingRequiredError is returned if a required flag or argument isn't set.
+type MissingRequiredError struct {
+	ParseError
+	Name string // Name of the flag or argument
+	Flag bool   // True if Name refers to a flag
+}
+
+func (e *MissingRequiredError) Error() string {
+	if e.Flag {
+		return fmt.Sprintf("required flag [%s] not set", e.Name)
+	}
+	return fmt.Sprintf("required argument <%s> not set", e.Name)
+}
+
+// ArgumentCountError is returned if the number of values of a vararg, or the
+// number of positional arguments of a command, is out of range.
+type ArgumentCountError struct {
+	ParseError
+	Name  string // Name of the vararg, empty for the positional arguments of the command
+	Count int    // Number of values passed
+	Min   int    // Minimum number of values
+	Max   int    // Maximum number of values, 0 if unlimited, NoArgs (-1) if none are accepted
+}
+
+func (e *ArgumentCountError) Error() string {
+	values := "arguments"
+	if e.Name != "" {
+		values = "values for <" + e.Name + ">"
+	}
+
+	switch {
+	case e.Max == NoArgs:
+		return fmt.Sprintf("expected no arguments, got %d", e.Count)
+	case e.Min == e.Max:
+		return fmt.Sprintf("expected %d %s, got %d", e.Min, values, e.Count)
+	case e.Count < e.Min:
+		return fmt.Sprintf("expected at least %d %s, got %d", e.Min, values, e.Count)
+	}
+	return fmt.Sprintf("expected at most %d %s, got %d", e.Max, values, e.Count)
+}
+
+// ValidationError is returned if a constraint or the Validate function of a
+// command fails.
+type ValidationError struct {
+	ParseError
+	Err error // Error returned by the validation
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,111 @@
+package cli_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestRun_ShouldReturnUsageErrors(t *testing.T) {
+	sub := cli.Command{
+		Name:  "sub",
+		Args:  []cli.Arg{{Name: "count", Parser: cli.Int32Parser}},
+		Flags: []cli.Flag{{Name: "user", Short: "u", HasValue: true}, {Name: "pass", Required: true}},
+	}
+	files := cli.Command{Name: "files", Args: []cli.Arg{{Name: "files", Vararg: true, Min: 2}}}
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{sub, files}}
+
+	tests := []struct {
+		name      string
+		args      []string
+		target    interface{}
+		wantToken string
+		wantPath  string
+	}{{
+		name:      "UnknownFlag",
+		args:      []string{"sub", "--unknown"},
+		target:    new(*cli.UnknownFlagError),
+		wantToken: "--unknown",
+		wantPath:  "app sub",
+	}, {
+		name:      "UnknownCommand",
+		args:      []string{"unknown"},
+		target:    new(*cli.UnknownCommandError),
+		wantToken: "unknown",
+		wantPath:  "app",
+	}, {
+		name:      "UnexpectedArgument",
+		args:      []string{"sub", "1", "2", "--pass"},
+		target:    new(*cli.UnexpectedArgumentError),
+		wantToken: "2",
+		wantPath:  "app sub",
+	}, {
+		name:      "MissingValue",
+		args:      []string{"sub", "--pass", "-u"},
+		target:    new(*cli.MissingValueError),
+		wantToken: "-u",
+		wantPath:  "app sub",
+	}, {
+		name:      "InvalidValue",
+		args:      []string{"sub", "--pass", "test"},
+		target:    new(*cli.InvalidValueError),
+		wantToken: "test",
+		wantPath:  "app sub",
+	}, {
+		name:     "MissingRequired",
+		args:     []string{"sub", "1"},
+		target:   new(*cli.MissingRequiredError),
+		wantPath: "app sub",
+	}, {
+		name:     "ArgumentCount",
+		args:     []string{"files", "a"},
+		target:   new(*cli.ArgumentCountError),
+		wantPath: "app files",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cli.NewApp(&cmd).Run(ctx, tt.args)
+			if !errors.As(err, tt.target) {
+				t.Fatalf("expected error of type %T, got = %v", tt.target, err)
+			}
+			var usageErr cli.UsageError
+			if !errors.As(err, &usageErr) {
+				t.Fatalf("expected a usage error, got = %v", err)
+			}
+			details := usageErr.Details()
+			if details.Token != tt.wantToken {
+				t.Errorf("expected token = %q, got = %q", tt.wantToken, details.Token)
+			}
+			if details.Path != tt.wantPath {
+				t.Errorf("expected path = %q, got = %q", tt.wantPath, details.Path)
+			}
+			if details.UsageLine == "" {
+				t.Error("expected a usage line")
+			}
+		})
+	}
+}
+
+func TestRun_ShouldWrapParserError(t *testing.T) {
+	cmd := cli.Command{Args: []cli.Arg{{Name: "count", Parser: cli.Int32Parser}}}
+	err := cli.NewApp(&cmd).Run(ctx, []string{"test"})
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("expected a wrapped *strconv.NumError, got = %v", err)
+	}
+}
+
+func TestRun_ShouldReturnUsageLine(t *testing.T) {
+	sub := cli.Command{Name: "sub", Flags: []cli.Flag{{Name: "user"}}, Args: []cli.Arg{{Name: "name"}}}
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{sub}}
+	err := cli.NewApp(&cmd).Run(ctx, []string{"sub", "--unknown"})
+	var usageErr cli.UsageError
+	if !errors.As(err, &usageErr) {
+		t.Fatalf("expected a usage error, got = %v", err)
+	}
+	if want := "app sub [flags] <name>"; usageErr.Details().UsageLine != want {
+		t.Errorf("expected usage line = %q, got = %q", want, usageErr.Details().UsageLine)
+	}
+}
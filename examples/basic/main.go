@@ -11,16 +11,13 @@ import (
 
 // nolint:gomnd
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
 	var login = cli.Command{
 		Name: "print",
 		Flags: []cli.Flag{
 			{Name: "user", Short: "u", Required: true, Description: "User to run with.", HasValue: true},
 		},
 		Run: func(ctx context.Context, params cli.Params) error {
-			fmt.Printf("The passed user was: %s.\n", params["user"].(string))
+			fmt.Printf("The passed user was: %s.\n", cli.MustGet[string](params, "user"))
 			return nil
 		},
 	}
@@ -31,7 +28,8 @@ func main() {
 		Commands: []cli.Command{login},
 	}
 
-	if err := cli.Run(ctx, &c); err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-	}
+	app := cli.NewApp(&c)
+	app.HandleSignals = true
+	app.GracePeriod = 5 * time.Second
+	app.Main(context.Background(), os.Args[1:])
 }
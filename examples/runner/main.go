@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/exec"
 	"time"
@@ -11,13 +10,14 @@ import (
 )
 
 var Ls = cli.Command{
-	Name:  "ls",
-	Short: "Execute ls.",
-	Run:   runLs,
+	Name:                "ls",
+	Short:               "Execute ls, arguments after \"--\" are passed to ls.",
+	Run:                 runLs,
+	DisableInterspersed: true,
 }
 
-func runLs(ctx context.Context, _ cli.Params) error {
-	cmd := exec.CommandContext(ctx, "/bin/ls") // nolint:gosec
+func runLs(ctx context.Context, params cli.Params) error {
+	cmd := exec.CommandContext(ctx, "/bin/ls", params.RemainingArguments()...) // nolint:gosec
 	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
 	return cmd.Run()
 }
@@ -32,9 +32,6 @@ var Login = cli.Command{
 
 // nolint:gomnd
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-
 	commands := []cli.Command{
 		Ls,
 		Login,
@@ -47,7 +44,8 @@ func main() {
 		Commands: commands,
 	}
 
-	if err := cli.Run(ctx, &c); err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-	}
+	app := cli.NewApp(&c)
+	app.HandleSignals = true
+	app.GracePeriod = 5 * time.Second
+	app.Main(context.Background(), os.Args[1:])
 }
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes used by Main.
+const (
+	ExitCodeError       = 1   // Exit code for errors returned by a Runner
+	ExitCodeUsage       = 2   // Exit code for UsageErrors
+	ExitCodeInterrupted = 130 // Exit code if the process is forced to exit by a signal
+)
+
+// ExitError is an error with the exit code to use, returned by Exit.
+type ExitError struct {
+	Code int   // Exit code of the process
+	Err  error // Underlying error, nil if only the code is set
+}
+
+// Exit returns an error that makes Main exit with the given code. The error
+// may be nil, so nothing is printed.
+func Exit(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for an error returned by Run: 0 for nil, the
+// code of an ExitError, ExitCodeUsage for a UsageError and ExitCodeError for
+// any other error.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	var usageErr UsageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		return ExitCodeUsage
+	}
+	return ExitCodeError
+}
+
+// Main runs the command tree with the process arguments, using a default App,
+// and exits the process. See App.Main.
+func Main(ctx context.Context, cmd *Command) {
+	NewApp(cmd).Main(ctx, os.Args[1:])
+}
+
+// Main runs the app and exits with the ExitCode of the returned error, using
+// the Exit function. Errors are printed to Err, followed by the usage line for
+// a UsageError.
+func (a *App) Main(ctx context.Context, args []string) {
+	err := a.Run(ctx, args)
+
+	var exitErr *ExitError
+	var usageErr UsageError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.Err == nil:
+	case errors.As(err, &usageErr) && usageErr.Details().UsageLine != "":
+		fmt.Fprintf(a.stderr(), "Error: %v\nUsage: %s\n", err, usageErr.Details().UsageLine)
+	default:
+		fmt.Fprintf(a.stderr(), "Error: %v\n", err)
+	}
+
+	a.exit(ExitCode(err))
+}
+
+func (a *App) exit(code int) {
+	if a.Exit != nil {
+		a.Exit(code)
+		return
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,49 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestApp_ShouldExitWithCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		runErr     error
+		wantCode   int
+		wantOutput string
+	}{
+		{name: "Success", args: []string{"cmd"}},
+		{name: "Error", args: []string{"cmd"}, runErr: errors.New("failed"), wantCode: 1, wantOutput: "Error: failed\n"},
+		{name: "ExitCode", args: []string{"cmd"}, runErr: cli.Exit(3, errors.New("failed")), wantCode: 3,
+			wantOutput: "Error: failed\n"},
+		{name: "ExitCodeWithoutError", args: []string{"cmd"}, runErr: cli.Exit(4, nil), wantCode: 4},
+		{name: "UsageError", args: []string{"cmd", "--unknown"}, wantCode: 2,
+			wantOutput: "Error: unknown flag \"--unknown\"\nUsage: app cmd\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := cli.Command{Name: "cmd", Run: func(_ context.Context, _ cli.Params) error {
+				return tt.runErr
+			}}
+			buf := bytes.Buffer{}
+			app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{cmd}})
+			app.Err = &buf
+			code := -1
+			app.Exit = func(c int) {
+				code = c
+			}
+			app.Main(ctx, tt.args)
+			if code != tt.wantCode {
+				t.Errorf("expected code = %d, got = %d", tt.wantCode, code)
+			}
+			if buf.String() != tt.wantOutput {
+				t.Errorf("expected output = %q, got = %q", tt.wantOutput, buf.String())
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package cli
 
 import (
-	"fmt"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+var errNoValue = errors.New("flag doesn't take a value")
+
 // Flag that can be passed to commands. The name and short description should always
 // be set.
 type Flag struct {
@@ -14,26 +19,196 @@ type Flag struct {
 	Parser      ParserFunc  // Parser function to use
 	Required    bool        // If true, the execution will fail, if this flag is not set
 	Default     interface{} // Default value
+	Counter     bool        // If true, the flag can be repeated and the params map holds the count as int
+	Persistent  bool        // If true, the flag is also accepted by all sub-commands
+	EnvVar      string      // Environment variable used if the flag isn't set (Default: derived from App.EnvPrefix)
+	Choices     []string    // If set, only these values are accepted
+	IgnoreCase  bool        // If true, the choices are matched case insensitive
+	Repeatable  bool        // If true, the flag can be repeated and the values are collected in a slice
+	KeyValue    bool        // If true, the flag can be repeated and key=value pairs are collected in a map
+	Separator   string      // If set, values of repeatable flags are split at the separator, e.g. ","
+	Negatable   bool        // If true, a flag without value can be disabled with "--no-<name>"
+
+	builtin bool   // Built-in flags aren't bound to derived environment variables
+	load    Loader // Loader of config flags, which take the path of a config file
+	key     string // Key in the params map, if it differs from the name
+}
+
+// param returns the key of the flag in the params map.
+func (p *Flag) param() string {
+	if p.key != "" {
+		return p.key
+	}
+	return p.Name
 }
 
 func (p *Flag) matches(arg string) bool {
 	return arg == "-"+p.Short || arg == "--"+p.Name
 }
 
-func (p *Flag) parse(next string) interface{} {
+// negates reports whether arg is "--no-<name>" of a negatable boolean flag.
+func (p *Flag) negates(arg string) bool {
+	return p.Negatable && p.isBool() && arg == "--no-"+p.Name
+}
+
+// isBool reports whether the flag holds a bool in the params map.
+func (p *Flag) isBool() bool {
+	return !p.HasValue && !p.Counter
+}
+
+// isCluster reports whether arg can be a cluster of short flags, like "-abc".
+func isCluster(arg string) bool {
+	return len(arg) > 2 && arg[0] == '-' && arg[1] != '-'
+}
+
+// matchesAttached checks for a value attached to the flag, as in "--name=value",
+// "-s=value" or "-svalue". The last form is only accepted for flags with a value.
+func (p *Flag) matchesAttached(arg string) (value string, ok bool) {
+	if strings.HasPrefix(arg, "--"+p.Name+"=") {
+		return arg[len(p.Name)+3:], true
+	}
+	if p.Short == "" || strings.HasPrefix(arg, "--") || !strings.HasPrefix(arg, "-"+p.Short) {
+		return "", false
+	}
+
+	value = arg[len(p.Short)+1:]
+	if strings.HasPrefix(value, "=") {
+		return value[1:], true
+	}
+
+	return value, p.HasValue
+}
+
+func (p *Flag) parse(next string) (interface{}, error) {
 	if p.HasValue {
-		parser := StringParser
-		if p.Parser != nil {
-			parser = p.Parser
+		return p.parser()(next)
+	} else {
+		return true, nil
+	}
+}
+
+func (p *Flag) repeatable() bool {
+	return p.HasValue && (p.Repeatable || p.KeyValue)
+}
+
+// collect parses val and adds it to the values collected so far, which are
+// a typed slice, or a map for key=value flags.
+func (p *Flag) collect(collected interface{}, val string) (interface{}, error) {
+	parts := []string{val}
+	if p.Separator != "" {
+		parts = strings.Split(val, p.Separator)
+	}
+
+	for _, part := range parts {
+		if part == "" && p.Separator != "" {
+			continue
 		}
 
-		val, err := parser(next)
+		key := ""
+		if p.KeyValue {
+			var ok bool
+			if key, part, ok = strings.Cut(part, "="); !ok {
+				return collected, &FormatError{"key=value", nil}
+			}
+		}
+
+		parsed, err := p.parser()(part)
 		if err != nil {
-			fmt.Fprintln(Err, err.Error())
+			return collected, err
 		}
 
-		return val
-	} else {
-		return true
+		if !p.KeyValue {
+			collected = appendValue(collected, parsed)
+			continue
+		}
+
+		collected = setMapValue(collected, key, parsed)
+	}
+
+	return collected, nil
+}
+
+// setMapValue sets key to val in a map of its type. A new map is created if m
+// is nil. Values are collected in a map[string]interface{} instead, as soon as
+// one of them is nil or their types differ.
+func setMapValue(m interface{}, key string, val interface{}) interface{} {
+	if values, ok := m.(map[string]interface{}); ok {
+		values[key] = val
+		return values
+	}
+
+	t := reflect.TypeOf(val)
+	switch {
+	case m == nil && t == nil:
+		return map[string]interface{}{key: nil}
+	case m == nil:
+		m = reflect.MakeMap(reflect.MapOf(reflect.TypeOf(key), t)).Interface()
+	}
+	v := reflect.ValueOf(m)
+	if v.Type().Elem() == t {
+		v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(val))
+		return m
+	}
+
+	values := make(map[string]interface{}, v.Len()+1)
+	for iter := v.MapRange(); iter.Next(); {
+		values[iter.Key().String()] = iter.Value().Interface()
+	}
+	values[key] = val
+	return values
+}
+
+// parseValue parses a value read from the environment, a value source or a
+// string default. Flags without a value accept a boolean, counters an integer.
+func (p *Flag) parseValue(val string) (interface{}, error) {
+	switch {
+	case p.Counter:
+		return strconv.Atoi(val)
+	case !p.HasValue:
+		return parseBool(val)
+	case p.repeatable():
+		return p.collect(nil, val)
+	}
+	return p.parser()(val)
+}
+
+// parseValues parses the values of a value source. Repeatable flags collect
+// all values, others take the last one.
+func (p *Flag) parseValues(values []string) (val interface{}, err error) {
+	if !p.repeatable() {
+		return p.parseValue(values[len(values)-1])
+	}
+
+	for _, v := range values {
+		if val, err = p.collect(val, v); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// envVar returns the environment variable bound to the flag. If EnvVar isn't
+// set, the name is derived from the prefix, e.g. "APP_USER_NAME" for "user-name".
+func (p *Flag) envVar(prefix string) string {
+	if p.EnvVar != "" || prefix == "" || p.builtin {
+		return p.EnvVar
+	}
+	name := strings.TrimSuffix(prefix, "_") + "_" + strings.ReplaceAll(p.Name, "-", "_")
+	return strings.ToUpper(name)
+}
+
+func (p *Flag) parser() ParserFunc {
+	if p.Parser != nil {
+		return choiceParser(p.Parser, p.Choices, p.IgnoreCase)
+	}
+	return choiceParser(StringParser, p.Choices, p.IgnoreCase)
+}
+
+// defaultValue returns the default value of the flag. String defaults are
+// parsed like values from the environment.
+func (p *Flag) defaultValue() (interface{}, error) {
+	if str, ok := p.Default.(string); ok {
+		return p.parseValue(str)
 	}
+	return p.Default, nil
 }
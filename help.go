@@ -16,19 +16,24 @@ var helpTemplate string
 // Print the help text for a command.
 // This includes the description (if any), the arguments, the parameters and
 // available sub-commands.
-func (c *Command) printHelp() {
+func (c *Command) printHelp(app *App) {
 	funcMap := template.FuncMap{
 		"usage":            c.Usage,
 		"formatArg":        templateFormatArg(c),
 		"formatSubCommand": templateFormatSubCommand(c),
 		"groups":           templateCommandGroups(c),
+		"globalFlags":      c.inheritedFlags,
+		"constraints":      c.constraints,
+		"commandNames":     func(cmd Command) string { return strings.Join(cmd.names(), ", ") },
+		"flagEnvVar":       func(flag Flag) string { return flag.envVar(app.EnvPrefix) },
+		"join":             strings.Join,
 	}
 
 	tmpl, err := template.New("help").Funcs(funcMap).Parse(helpTemplate)
 	if err != nil {
 		panic(err)
 	}
-	err = tmpl.Execute(Out, c)
+	err = tmpl.Execute(app.stdout(), c)
 	if err != nil {
 		panic(err)
 	}
@@ -48,8 +53,12 @@ func (c *Command) Usage() string {
 
 	if c.hasArgs() {
 		str := make([]string, 0, len(c.Args))
-		for _, flag := range c.Args {
-			str = append(str, "<"+flag.Name+">")
+		for _, arg := range c.Args {
+			if arg.Vararg {
+				str = append(str, "<"+arg.Name+">...")
+			} else {
+				str = append(str, "<"+arg.Name+">")
+			}
 		}
 		fmt.Fprint(&buf, strings.Join(str, " "))
 	}
@@ -90,20 +99,11 @@ func templateCommandGroups(c *Command) func() map[string][]Command {
 	}
 }
 
-func isHelp(arg string) bool {
-	for _, flag := range defaultHelpFlags {
-		if arg == flag {
-			return true
-		}
-	}
-	return false
-}
-
 func subCommandMaxLen(c *Command) int {
 	nameWidth := 3
 	for _, cmd := range c.Commands {
-		if len(cmd.Name) > nameWidth {
-			nameWidth = len(cmd.Name)
+		if names := strings.Join(cmd.names(), ", "); len(names) > nameWidth {
+			nameWidth = len(names)
 		}
 	}
 	return nameWidth
@@ -7,22 +7,39 @@
 {{ range $group, $commands := groups }}
 {{ if $group }}{{ $group -}}{{ else }}Available Commands{{ end }}:
   {{- range $commands -}}
-    {{ .Name | formatSubCommand -}}{{ .Short }}
+    {{ commandNames . | formatSubCommand -}}{{ .Short }}
   {{ end -}}
 {{ end }}
 
 {{- if .Args }}
 Arguments:
 {{- range .Args }}
-  {{ .Name | formatArg }}{{ .Description }}{{ if not .Required }} {{ if .Default }}(Default: {{ .Default }}){{ else }}(Optional){{ end }}{{ end -}}
+  {{ .Name | formatArg }}{{ .Description }}{{ with .Choices }} ({{ join . "|" }}){{ end }}{{ with .EnvVar }} [${{ . }}]{{ end }}{{ if not .Required }} {{ if .Default }}(Default: {{ .Default }}){{ else }}(Optional){{ end }}{{ end -}}
 {{ end }}
 {{ end }}
 
 {{- if .Flags }}
 Flags:
 {{- range .Flags }}
-  {{ if .Short }}-{{ .Short }},{{ end }}--{{ .Name }}{{ if .Default }}={{ .Default }}{{ end }}:     {{ .Description }}
+  {{ template "flag" . }}
 {{ end -}}
 {{ end }}
+
+{{- with globalFlags }}
+Global Flags:
+{{- range . }}
+  {{ template "flag" . }}
+{{ end -}}
+{{ end }}
+{{- with constraints }}
+Constraints:
+{{- range . }}
+  {{ . }}
+{{- end }}
+{{ end }}
 Usage:
   {{ usage }}
+
+{{- define "flag" -}}
+{{ if .Short }}-{{ .Short }},{{ end }}--{{ if and .Negatable (not .HasValue) (not .Counter) }}[no-]{{ end }}{{ .Name }}{{ if .Default }}={{ .Default }}{{ end }}:     {{ .Description }}{{ with .Choices }} ({{ join . "|" }}){{ end }}{{ if or .Repeatable .KeyValue }} (repeatable){{ end }}{{ with flagEnvVar . }} [${{ . }}]{{ end }}
+{{- end -}}
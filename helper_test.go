@@ -1,8 +1,22 @@
 package cli_test
 
+import "strconv"
+
 func osArgs(args []string) []string {
 	a := make([]string, 0, len(args)+1)
 	a = append(a, "cmd")
 	a = append(a, args...)
 	return a
 }
+
+// looseParser returns an int for numbers, nil for "none" and the string
+// otherwise.
+func looseParser(val string) (interface{}, error) {
+	if val == "none" {
+		return nil, nil
+	}
+	if i, err := strconv.Atoi(val); err == nil {
+		return i, nil
+	}
+	return val, nil
+}
@@ -1,14 +1,20 @@
 package cli
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+)
 
-func lint(cmd *Command) []error {
+func lint(cmd *Command, root bool) []error {
 	errs := make([]error, 0)
 
+	if err := cmd.bindOptions(); err != nil {
+		errs = append(errs, err)
+	}
 	if cmd.Name == "" {
 		errs = append(errs, fmt.Errorf("missing name on command %+v", cmd))
 	}
-	if root != cmd && cmd.Short == "" {
+	if !root && cmd.Short == "" {
 		errs = append(errs, fmt.Errorf("missing short description on command %s", cmd.Name))
 	}
 	if cmd.hasFlags() {
@@ -19,14 +25,160 @@ func lint(cmd *Command) []error {
 			if param.Description == "" {
 				errs = append(errs, fmt.Errorf("missing description on param %s in command %s", param.Name, cmd.Name))
 			}
+			if (param.Repeatable || param.KeyValue) && !param.HasValue {
+				errs = append(errs, fmt.Errorf("repeatable param %s in command %s doesn't have a value", param.Name, cmd.Name))
+			}
+			if err := lintFlagDefault(param); err != nil {
+				errs = append(errs, fmt.Errorf("invalid default on param %s in command %s: %w", param.Name, cmd.Name, err))
+			}
 		}
 	}
+	if cmd.hasArgs() {
+		varargs := 0
+		for _, arg := range cmd.Args {
+			if err := lintArgDefault(arg); err != nil {
+				errs = append(errs, fmt.Errorf("invalid default on argument %s in command %s: %w", arg.Name, cmd.Name, err))
+			}
+			if !arg.Vararg {
+				continue
+			}
+			if varargs++; varargs == 2 {
+				errs = append(errs, fmt.Errorf("more than one vararg in command %s", cmd.Name))
+			}
+			if arg.Max > 0 && arg.Max < arg.Min {
+				errs = append(errs, fmt.Errorf("max count of argument %s in command %s is less than min count", arg.Name, cmd.Name))
+			}
+		}
+	}
+	for _, constraint := range cmd.Constraints {
+		for _, name := range constraint.flags {
+			if !cmd.hasFlag(name) {
+				errs = append(errs, fmt.Errorf("unknown flag %s in constraint of command %s", name, cmd.Name))
+			}
+		}
+	}
+	if cmd.MaxArgs > 0 && cmd.MaxArgs < cmd.MinArgs {
+		errs = append(errs, fmt.Errorf("max args of command %s is less than min args", cmd.Name))
+	}
 	if cmd.hasSubCommands() {
+		seen := make(map[string]bool)
 		for _, sub := range cmd.Commands {
-			s := sub
-			errs = append(errs, lint(&s)...)
+			for _, name := range sub.names() {
+				if seen[name] {
+					errs = append(errs, fmt.Errorf("duplicate command name %s in command %s", name, cmd.Name))
+				}
+				seen[name] = true
+			}
+		}
+		// Sub-commands are linted with the persistent flags they inherit
+		for _, sub := range cmd.Commands {
+			errs = append(errs, lint(cmd.subCommand(sub.Name), false)...)
 		}
 	}
 
 	return errs
 }
+
+func lintFlagDefault(flag Flag) error {
+	_, isString := flag.Default.(string)
+	switch {
+	case flag.Default == nil:
+		return nil
+	case isString:
+		_, err := flag.defaultValue()
+		return err
+	case flag.Counter:
+		return lintDefaultType(flag.Default, 0)
+	case !flag.HasValue:
+		return lintDefaultType(flag.Default, true)
+	case flag.repeatable():
+		return lintCollectedDefault(flag)
+	}
+	return lintDefault(flag.Default, flag.parser())
+}
+
+// lintCollectedDefault checks that the default of a repeatable or key=value
+// flag has the type of the slice or map its values are collected in.
+func lintCollectedDefault(flag Flag) error {
+	kind := reflect.Slice
+	if flag.KeyValue {
+		kind = reflect.Map
+	}
+	v := reflect.ValueOf(flag.Default)
+	if v.Kind() != kind {
+		return fmt.Errorf("default is of type %T, expected a %s", flag.Default, kind)
+	}
+
+	var collected interface{}
+	parse := func(val reflect.Value) (interface{}, error) {
+		return flag.parser()(fmt.Sprint(val.Interface()))
+	}
+	if kind == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			parsed, err := parse(v.Index(i))
+			if err != nil {
+				return err
+			}
+			collected = appendValue(collected, parsed)
+		}
+	} else {
+		for iter := v.MapRange(); iter.Next(); {
+			parsed, err := parse(iter.Value())
+			if err != nil {
+				return err
+			}
+			collected = setMapValue(collected, fmt.Sprint(iter.Key().Interface()), parsed)
+		}
+	}
+	if collected == nil {
+		return nil
+	}
+
+	return lintDefaultType(flag.Default, collected)
+}
+
+func lintArgDefault(arg Arg) error {
+	if !arg.Vararg {
+		return lintDefault(arg.Default, arg.parser())
+	}
+
+	// Vararg defaults are parsed value by value
+	switch arg.Default.(type) {
+	case string, []string:
+		_, err := arg.defaultValue()
+		return err
+	}
+	if arg.Default != nil && reflect.TypeOf(arg.Default).Kind() != reflect.Slice {
+		return fmt.Errorf("default of vararg is of type %T, expected a slice", arg.Default)
+	}
+	return nil
+}
+
+// lintDefault checks that a default value can be parsed by the parser, and
+// that non-string defaults have the type the parser produces.
+func lintDefault(def interface{}, parser ParserFunc) error {
+	if def == nil {
+		return nil
+	}
+
+	str, isString := def.(string)
+	if !isString {
+		str = fmt.Sprint(def)
+	}
+	val, err := parser(str)
+	if err != nil {
+		return err
+	}
+	if isString {
+		return nil
+	}
+
+	return lintDefaultType(def, val)
+}
+
+func lintDefaultType(def interface{}, parsed interface{}) error {
+	if reflect.TypeOf(def) != reflect.TypeOf(parsed) {
+		return fmt.Errorf("default is of type %T, but the parser returns %T", def, parsed)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrParamNotSet is returned by Get if a flag or argument isn't set.
+	ErrParamNotSet = errors.New("not set")
+	// ErrParamType is returned by Get if a flag or argument has a different type.
+	ErrParamType = errors.New("unexpected type")
+)
+
+// Get returns the value of the flag or argument with the given name. It fails
+// if the param isn't set or isn't of type T.
+func Get[T any](params Params, name string) (T, error) {
+	var zero T
+	val, ok := params[name]
+	if !ok {
+		return zero, fmt.Errorf("param %q: %w", name, ErrParamNotSet)
+	}
+
+	typed, ok := val.(T)
+	if !ok {
+		return zero, fmt.Errorf("param %q: %w: %T instead of %T", name, ErrParamType, val, zero)
+	}
+
+	return typed, nil
+}
+
+// Lookup returns the value of the flag or argument with the given name, and
+// whether it is set with type T.
+func Lookup[T any](params Params, name string) (T, bool) {
+	val, err := Get[T](params, name)
+	return val, err == nil
+}
+
+// GetOr returns the value of the flag or argument with the given name, or def
+// if it isn't set with type T.
+func GetOr[T any](params Params, name string, def T) T {
+	if val, ok := Lookup[T](params, name); ok {
+		return val
+	}
+	return def
+}
+
+// MustGet is like Get but panics if the param isn't set or isn't of type T.
+func MustGet[T any](params Params, name string) T {
+	val, err := Get[T](params, name)
+	if err != nil {
+		panic(err)
+	}
+	return val
+}
@@ -0,0 +1,73 @@
+package cli_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestGet(t *testing.T) {
+	params := cli.Params{"user": "joe", "count": int32(3)}
+
+	if user, err := cli.Get[string](params, "user"); err != nil || user != "joe" {
+		t.Errorf("expected user = joe, got = %v, %v", user, err)
+	}
+
+	_, err := cli.Get[string](params, "pass")
+	if !errors.Is(err, cli.ErrParamNotSet) || !strings.Contains(err.Error(), `"pass"`) {
+		t.Errorf("expected a not set error naming the param, got = %v", err)
+	}
+
+	_, err = cli.Get[string](params, "count")
+	if !errors.Is(err, cli.ErrParamType) || !strings.Contains(err.Error(), `"count"`) {
+		t.Errorf("expected a type error naming the param, got = %v", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	params := cli.Params{"user": "joe"}
+
+	if user, ok := cli.Lookup[string](params, "user"); !ok || user != "joe" {
+		t.Errorf("expected user = joe, got = %v, %v", user, ok)
+	}
+	if _, ok := cli.Lookup[int](params, "user"); ok {
+		t.Error("expected lookup with a different type to fail")
+	}
+	if _, ok := cli.Lookup[string](params, "pass"); ok {
+		t.Error("expected lookup of a missing param to fail")
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	params := cli.Params{"user": "joe"}
+
+	if user := cli.GetOr(params, "user", "jane"); user != "joe" {
+		t.Errorf("expected user = joe, got = %v", user)
+	}
+	if pass := cli.GetOr(params, "pass", "secret"); pass != "secret" {
+		t.Errorf("expected pass = secret, got = %v", pass)
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	params := cli.Params{"user": "joe"}
+
+	if user := cli.MustGet[string](params, "user"); user != "joe" {
+		t.Errorf("expected user = joe, got = %v", user)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustGet to panic")
+		}
+	}()
+	cli.MustGet[string](params, "pass")
+}
+
+func TestParams_AdditionalArguments(t *testing.T) {
+	if args := (cli.Params{}).AdditionalArguments(); args != nil {
+		t.Errorf("expected no arguments, got = %v", args)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -18,136 +19,511 @@ func StringParser(val string) (interface{}, error) {
 func Int32Parser(val string) (interface{}, error) {
 	i64, err := strconv.ParseInt(val, 10, 32) // nolint:gomnd
 	if err != nil {
-		return int32(-1), err
+		return int32(-1), &FormatError{"a 32 bit integer, e.g. 42", err}
 	}
 
 	return int32(i64), nil
 }
 
+// isFlag reports whether arg is a flag. A single "-" is a positional argument,
+// commonly used for stdin.
 func isFlag(arg string) bool {
-	return strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--")
+	return len(arg) > 1 && strings.HasPrefix(arg, "-")
 }
 
-func parseArgs(cmd *Command, args *[]string) Params {
-	params := make(Params)
-	params["_args"] = *args
+// parser collects the flags and positional arguments of a single command.
+type parser struct {
+	app    *App
+	cmd    *Command
+	path   []string // Names of the commands leading to cmd, including cmd
+	params Params
+
+	// If true, all following arguments are positional, e.g. after "--"
+	terminated bool
+	// Positional arguments, assigned to cmd.Args once all are known
+	positional []positional
+}
 
-	skip := 0
-	notFound := []string{}
-	for i, arg := range *args {
-		if skip > 0 {
-			skip--
-			continue
+type positional struct {
+	value      string
+	terminated bool // If true, the argument was passed after "--"
+}
+
+func newParser(app *App, cmd *Command, path []string, args []string, params Params) *parser {
+	params["_args"] = args
+
+	return &parser{app: app, cmd: cmd, path: path, params: params}
+}
+
+// parseFlag parses the flag at args[0]. It returns the number of additional
+// arguments consumed as the flag value.
+func (p *parser) parseFlag(args []string) (skip int, err error) {
+	flag, value, attached := p.cmd.flag(args[0])
+	if flag == nil && p.app.PrefixMatching {
+		if flag, value, attached, err = p.prefixFlag(args[0]); err != nil {
+			return 0, err
 		}
+	}
+	if flag != nil {
+		return p.parseFlagValue(flag, args, value, attached)
+	}
+	if isCluster(args[0]) {
+		return p.parseCluster(args)
+	}
 
-		var found bool
-		if !isFlag(arg) {
-			skip, found = parseArguments(cmd, params, i, args)
-			if !found {
-				notFound = append(notFound, arg)
-			}
+	return 0, &UnknownFlagError{p.parseError(args[0]), p.suggestFlag(args[0])}
+}
 
+// prefixFlag returns the long flag starting with the name given in arg, and its
+// attached value. Returns nil if no flag matches.
+func (p *parser) prefixFlag(arg string) (flag *Flag, value string, attached bool, err error) {
+	if !strings.HasPrefix(arg, "--") {
+		return nil, "", false, nil
+	}
+	prefix, val, hasValue := strings.Cut(arg[2:], "=")
+
+	flags := p.cmd.allFlags()
+	candidates := make([]string, 0)
+	for i := range flags {
+		switch {
+		case strings.HasPrefix(flags[i].Name, prefix):
+			flag, value, attached = &flags[i], val, hasValue
+		case flags[i].Negatable && flags[i].isBool() && !hasValue && strings.HasPrefix("no-"+flags[i].Name, prefix):
+			flag, value, attached = &flags[i], "false", true
+		default:
 			continue
 		}
+		candidates = append(candidates, "--"+flags[i].Name)
+	}
+
+	if len(candidates) > 1 {
+		return nil, "", false, &AmbiguousError{p.parseError(arg), candidates, true}
+	}
+	return flag, value, attached, nil
+}
 
-		skip, found = parseFlags(cmd, params, i, args)
-		if !found {
-			notFound = append(notFound, arg)
+// subCommand returns the sub-command addressed by arg, or nil. With prefix
+// matching, arg can be the prefix of a single sub-command.
+func (p *parser) subCommand(arg string) (*Command, error) {
+	if sub := p.cmd.subCommand(arg); sub != nil || !p.app.PrefixMatching {
+		return sub, nil
+	}
+
+	names := p.cmd.subCommandsWithPrefix(arg)
+	switch len(names) {
+	case 0:
+		return nil, nil
+	case 1:
+		return p.cmd.subCommand(names[0]), nil
+	}
+	return nil, &AmbiguousError{p.parseError(arg), names, false}
+}
+
+// parseCluster parses combined short flags like "-abc". The last flag of the
+// cluster may take a value, either attached ("-xfvalue") or as the next argument
+// ("-xf value").
+func (p *parser) parseCluster(args []string) (skip int, err error) {
+	for rest := args[0][1:]; rest != ""; {
+		flag := p.cmd.shortFlag(rest)
+		if flag == nil {
+			return 0, &UnknownFlagError{p.parseError(args[0]), p.suggestFlag(args[0])}
+		}
+
+		rest = rest[len(flag.Short):]
+		if flag.HasValue && rest != "" {
+			return p.parseFlagValue(flag, args, strings.TrimPrefix(rest, "="), true)
+		}
+		if skip, err = p.parseFlagValue(flag, args, "", false); err != nil || flag.HasValue {
+			return skip, err
 		}
 	}
-	*args = notFound
 
-	for _, arg := range cmd.Args {
-		if _, exists := params[arg.Name]; !exists && arg.Default != nil {
-			params[arg.Name] = arg.Default
+	return 0, nil
+}
+
+// parseFlagValue stores the value of the flag at args[0]. If no value is
+// attached to the flag, it's taken from args[1].
+func (p *parser) parseFlagValue(flag *Flag, args []string, next string, attached bool) (skip int, err error) {
+	if attached && flag.isBool() {
+		val, err := parseBool(next)
+		if err != nil {
+			return 0, &InvalidValueError{p.parseError(next), "--" + flag.Name, err}
 		}
+		p.params[flag.param()] = val
+
+		return 0, nil
+	}
+	if attached && !flag.HasValue {
+		return 0, &InvalidValueError{p.parseError(next), "--" + flag.Name, errNoValue}
 	}
 
-	return params
+	if flag.HasValue && !attached {
+		if len(args) < 2 {
+			return 0, &MissingValueError{p.parseError(args[0])}
+		}
+		next = args[1]
+		skip++
+	}
+
+	if flag.Counter {
+		count, _ := p.params[flag.param()].(int)
+		p.params[flag.param()] = count + 1
+
+		return skip, nil
+	}
+
+	if flag.repeatable() {
+		collected, err := flag.collect(p.params[flag.param()], next)
+		if err != nil {
+			return 0, &InvalidValueError{p.parseError(next), "--" + flag.Name, err}
+		}
+		p.params[flag.param()] = collected
+
+		return skip, nil
+	}
+
+	val, err := flag.parse(next)
+	if err != nil {
+		return 0, &InvalidValueError{p.parseError(next), "--" + flag.Name, err}
+	}
+	p.params[flag.param()] = val
+
+	return skip, nil
 }
 
-func parseFlags(cmd *Command, params Params, index int, args *[]string) (skip int, found bool) {
-	for _, param := range cmd.Flags {
-		if params[param.Name] != nil || !param.matches((*args)[index]) {
-			continue
+// parseArgument queues arg as positional argument.
+func (p *parser) parseArgument(arg string) {
+	p.positional = append(p.positional, positional{arg, p.terminated})
+}
+
+// assignArguments assigns the queued positional arguments to cmd.Args. If the
+// command has a vararg, the fixed arguments before and after it take their
+// values first, e.g. "cp <src>... <dst>".
+func (p *parser) assignArguments() error {
+	args, values := p.cmd.Args, p.positional
+	vararg := -1
+	for i := range args {
+		if args[i].Vararg {
+			vararg = i
+			break
 		}
+	}
+
+	if vararg < 0 {
+		for i, val := range values {
+			if i < len(args) {
+				if err := p.assignArgument(args[i], val.value); err != nil {
+					return err
+				}
 
-		next := ""
-		if param.HasValue && len(*args) > index+1 {
-			next = (*args)[index+1]
+				continue
+			}
+
+			if err := p.assignRest(val); err != nil {
+				return err
+			}
 		}
 
-		params[param.Name] = param.parse(next)
-		found = true
+		return nil
+	}
+
+	lead := min(len(values), vararg)
+	trail := min(len(values)-lead, len(args)-vararg-1)
+	for i := 0; i < lead; i++ {
+		if err := p.assignArgument(args[i], values[i].value); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < trail; i++ {
+		if err := p.assignArgument(args[vararg+1+i], values[len(values)-trail+i].value); err != nil {
+			return err
+		}
+	}
 
-		if param.HasValue {
-			skip++
+	for _, val := range values[lead : len(values)-trail] {
+		parsed, err := args[vararg].parse(val.value)
+		if err != nil {
+			return &InvalidValueError{p.parseError(val.value), "<" + args[vararg].Name + ">", err}
 		}
+		p.params[args[vararg].Name] = appendValue(p.params[args[vararg].Name], parsed)
+	}
 
-		break
+	return nil
+}
+
+func (p *parser) assignArgument(arg Arg, val string) error {
+	parsed, err := arg.parse(val)
+	if err != nil {
+		return &InvalidValueError{p.parseError(val), "<" + arg.Name + ">", err}
 	}
-	return
+	p.params[arg.Name] = parsed
+
+	return nil
 }
 
-func parseArguments(cmd *Command, params Params, index int, args *[]string) (skip int, found bool) {
-	for _, param := range cmd.Args {
-		if params[param.Name] != nil {
-			continue
+// assignRest stores a positional argument exceeding cmd.Args. That's only
+// allowed after "--".
+func (p *parser) assignRest(val positional) error {
+	switch {
+	case val.terminated:
+		rest, _ := p.params[restKey].([]string)
+		p.params[restKey] = append(rest, val.value)
+		return nil
+	case p.cmd.hasSubCommands():
+		return &UnknownCommandError{p.parseError(val.value), p.suggestCommand(val.value)}
+	}
+	return &UnexpectedArgumentError{p.parseError(val.value)}
+}
+
+// unknownCommand returns an UnknownCommandError if arg exceeds the
+// positional arguments of a command with sub-commands. It's reported right
+// away, as the following flags likely belong to the mistyped sub-command.
+func (p *parser) unknownCommand(arg string) error {
+	if !p.cmd.hasSubCommands() || p.cmd.DisableInterspersed || len(p.positional) < len(p.cmd.Args) {
+		return nil
+	}
+	for _, a := range p.cmd.Args {
+		if a.Vararg {
+			return nil
 		}
+	}
+	return &UnknownCommandError{p.parseError(arg), p.suggestCommand(arg)}
+}
 
-		if param.Vararg {
-			skip, found = parseVargs(param, params, index, args)
-			break
+// appendValue appends val to a slice of its type. A new slice is created if
+// slice is nil. Values are collected in an []interface{} instead, as soon as
+// one of them is nil or their types differ.
+func appendValue(slice interface{}, val interface{}) interface{} {
+	if values, ok := slice.([]interface{}); ok {
+		return append(values, val)
+	}
+
+	t := reflect.TypeOf(val)
+	switch {
+	case slice == nil && t == nil:
+		return []interface{}{nil}
+	case slice == nil:
+		slice = reflect.MakeSlice(reflect.SliceOf(t), 0, 1).Interface()
+	}
+	v := reflect.ValueOf(slice)
+	if v.Type().Elem() == t {
+		return reflect.Append(v, reflect.ValueOf(val)).Interface()
+	}
+
+	values := make([]interface{}, v.Len(), v.Len()+1)
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return append(values, val)
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
 		}
+	}
+	return m
+}
+
+// finish fills the params not set on the command line, from the environment,
+// the value sources and the defaults in that order, and checks the required
+// params. Unset boolean flags are set to false afterwards. Persistent flags are
+// only handled if the command is the one to run.
+func (p *parser) finish(final bool, sources []ValueSource) error {
+	if err := p.applyEnv(final); err != nil {
+		return err
+	}
+	if err := p.applySources(final, sources); err != nil {
+		return err
+	}
+
+	// Flags count as set for the constraints unless they use their default
+	set := make(map[string]bool, len(p.params))
+	for name := range p.params {
+		set[name] = true
+	}
+
+	if err := p.applyDefaults(final); err != nil {
+		return err
+	}
 
-		params[param.Name] = param.parse((*args)[index])
-		found = true
+	if err := p.checkRequiredParams(final); err != nil {
+		return err
+	}
+	if err := p.validate(final, set); err != nil {
+		return err
+	}
 
-		break
+	// Boolean flags are always set, so the params hold a real bool
+	for _, flag := range p.flags(final) {
+		if _, exists := p.params[flag.param()]; !exists && flag.isBool() && !flag.builtin {
+			p.params[flag.param()] = false
+		}
 	}
 
-	return
+	return nil
 }
 
-func parseVargs(param Arg, params Params, index int, args *[]string) (skip int, found bool) {
-	params[param.Name] = []string{}
-	for len(*args) > index+skip {
-		cur := (*args)[index+skip]
+func (p *parser) applyEnv(final bool) error {
+	for _, arg := range p.cmd.Args {
+		if _, exists := p.params[arg.Name]; exists || arg.EnvVar == "" {
+			continue
+		}
 
-		if isFlag(cur) {
-			skip--
+		env, ok := p.app.lookupEnv(arg.EnvVar)
+		if !ok {
+			continue
+		}
+		val, err := arg.parseValue(env)
+		if err != nil {
+			return &InvalidValueError{p.parseError(env), "$" + arg.EnvVar, err}
+		}
+		p.params[arg.Name] = val
+	}
 
-			break
+	for _, flag := range p.flags(final) {
+		name := flag.envVar(p.app.EnvPrefix)
+		if _, exists := p.params[flag.param()]; exists || name == "" {
+			continue
 		}
 
-		params[param.Name] = append(params[param.Name].([]string), param.parse(cur).(string))
-		found = true
-		skip++
+		env, ok := p.app.lookupEnv(name)
+		if !ok {
+			continue
+		}
+		val, err := flag.parseValue(env)
+		if err != nil {
+			return &InvalidValueError{p.parseError(env), "$" + name, err}
+		}
+		p.params[flag.param()] = val
 	}
 
-	return
+	return nil
 }
 
-func checkRequiredParams(cmd *Command, params map[string]interface{}) error {
-	for _, arg := range cmd.Args {
-		if !arg.Required {
+func (p *parser) applySources(final bool, sources []ValueSource) error {
+	for _, flag := range p.flags(final) {
+		if _, exists := p.params[flag.param()]; exists {
 			continue
 		}
 
-		if _, ok := params[arg.Name]; !ok {
-			return fmt.Errorf("required argument <%s> not set", arg.Name)
+		values, ok := lookupSources(sources, p.path[1:], flag.Name)
+		if !ok {
+			continue
+		}
+		val, err := flag.parseValues(values)
+		if err != nil {
+			return &InvalidValueError{p.parseError(strings.Join(values, ",")), "--" + flag.Name, err}
 		}
+		p.params[flag.param()] = val
 	}
 
-	for _, flag := range cmd.Flags {
+	return nil
+}
+
+func (p *parser) applyDefaults(final bool) error {
+	for _, arg := range p.cmd.Args {
+		if _, exists := p.params[arg.Name]; exists || arg.Default == nil {
+			continue
+		}
+
+		val, err := arg.defaultValue()
+		if err != nil {
+			return &InvalidValueError{p.parseError(fmt.Sprint(arg.Default)), "<" + arg.Name + ">", err}
+		}
+		p.params[arg.Name] = val
+	}
+
+	for _, flag := range p.flags(final) {
+		if _, exists := p.params[flag.param()]; exists || flag.Default == nil {
+			continue
+		}
+
+		val, err := flag.defaultValue()
+		if err != nil {
+			return &InvalidValueError{p.parseError(fmt.Sprint(flag.Default)), "--" + flag.Name, err}
+		}
+		p.params[flag.param()] = val
+	}
+
+	return nil
+}
+
+func (p *parser) checkRequiredParams(final bool) error {
+	for _, arg := range p.cmd.Args {
+		val, ok := p.params[arg.Name]
+		if !ok && arg.Required {
+			return &MissingRequiredError{p.parseError(""), arg.Name, false}
+		}
+
+		if count := arg.count(val); arg.Vararg && (count < arg.Min || (arg.Max > 0 && count > arg.Max)) {
+			return &ArgumentCountError{p.parseError(""), arg.Name, count, arg.Min, arg.Max}
+		}
+	}
+
+	for _, flag := range p.flags(final) {
 		if !flag.Required {
 			continue
 		}
 
-		if _, ok := params[flag.Name]; !ok {
-			return fmt.Errorf("required flag [%s] not set", flag.Name)
+		if _, ok := p.params[flag.param()]; !ok {
+			return &MissingRequiredError{p.parseError(""), flag.Name, true}
+		}
+	}
+
+	return nil
+}
+
+// validate checks the number of positional arguments and the constraints of
+// the command. The Validate function is only called if the command is the one
+// to run.
+func (p *parser) validate(final bool, set map[string]bool) error {
+	if count := len(p.positional); !p.cmd.checkArity(count) {
+		return &ArgumentCountError{p.parseError(""), "", count, p.cmd.MinArgs, p.cmd.MaxArgs}
+	}
+
+	for _, constraint := range p.cmd.Constraints {
+		if err := constraint.check(set); err != nil {
+			return &ValidationError{p.parseError(""), err}
+		}
+	}
+
+	if final && p.cmd.Validate != nil {
+		if err := p.cmd.Validate(p.params); err != nil {
+			return &ValidationError{p.parseError(""), err}
 		}
 	}
 
 	return nil
 }
+
+// flags returns the flags to apply values to once the command line is parsed.
+// Persistent flags are left to the command to run.
+func (p *parser) flags(final bool) []Flag {
+	if final {
+		return p.cmd.allFlags()
+	}
+
+	flags := make([]Flag, 0, len(p.cmd.Flags))
+	for _, flag := range p.cmd.Flags {
+		if !flag.Persistent {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+func (p *parser) parseError(token string) ParseError {
+	usage := p.cmd.Usage()
+	if len(p.path) > 1 {
+		usage = strings.Join(p.path[:len(p.path)-1], " ") + " " + usage
+	}
+
+	return ParseError{
+		Token:     token,
+		Path:      strings.Join(p.path, " "),
+		UsageLine: strings.TrimSpace(usage),
+	}
+}
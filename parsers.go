@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"math"
+	"math/bits"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatError is returned by the parsers if a value doesn't have the expected
+// format.
+type FormatError struct {
+	Expected string // Description of the expected format
+	Err      error  // Underlying error, if any
+}
+
+func (e *FormatError) Error() string {
+	return "expected " + e.Expected
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1e12,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1e15,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+// choiceParser returns a parser that only accepts the given choices before
+// passing the value to parser. If ignoreCase is set, the value is replaced by
+// the matching choice.
+func choiceParser(parser ParserFunc, choices []string, ignoreCase bool) ParserFunc {
+	if len(choices) == 0 {
+		return parser
+	}
+
+	return func(val string) (interface{}, error) {
+		for _, choice := range choices {
+			if val == choice || (ignoreCase && strings.EqualFold(val, choice)) {
+				return parser(choice)
+			}
+		}
+
+		return nil, &FormatError{"one of " + strings.Join(choices, ", "), nil}
+	}
+}
+
+// IntParser returns the int representation of the given val.
+func IntParser(val string) (interface{}, error) {
+	i, err := strconv.ParseInt(val, 10, 0)
+	if err != nil {
+		return 0, &FormatError{"an integer, e.g. 42", err}
+	}
+
+	return int(i), nil
+}
+
+// Int64Parser returns the int64 representation of the given val.
+func Int64Parser(val string) (interface{}, error) {
+	i, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return int64(0), &FormatError{"a 64 bit integer, e.g. 42", err}
+	}
+
+	return i, nil
+}
+
+// UintParser returns the uint representation of the given val.
+func UintParser(val string) (interface{}, error) {
+	u, err := strconv.ParseUint(val, 10, 0)
+	if err != nil {
+		return uint(0), &FormatError{"a positive integer, e.g. 42", err}
+	}
+
+	return uint(u), nil
+}
+
+// Float64Parser returns the float64 representation of the given val.
+func Float64Parser(val string) (interface{}, error) {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return float64(0), &FormatError{"a number, e.g. 4.2", err}
+	}
+
+	return f, nil
+}
+
+// BoolParser returns the bool representation of the given val. It accepts
+// true/false, yes/no, on/off and 1/0, ignoring the case.
+func BoolParser(val string) (interface{}, error) {
+	return parseBool(val)
+}
+
+func parseBool(val string) (bool, error) {
+	switch strings.ToLower(val) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+
+	return false, &FormatError{"a boolean: true, false, yes, no, on, off, 1 or 0", nil}
+}
+
+// DurationParser returns the time.Duration representation of the given val,
+// e.g. "1h30m".
+func DurationParser(val string) (interface{}, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return time.Duration(0), &FormatError{"a duration, e.g. 1h30m or 500ms", err}
+	}
+
+	return d, nil
+}
+
+// TimeParser returns a parser for time.Time values in the given layout.
+func TimeParser(layout string) ParserFunc {
+	return func(val string) (interface{}, error) {
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return time.Time{}, &FormatError{"a time in the format " + layout, err}
+		}
+
+		return t, nil
+	}
+}
+
+// URLParser returns the *url.URL representation of the given val. Only
+// absolute URLs are accepted.
+func URLParser(val string) (interface{}, error) {
+	u, err := url.Parse(val)
+	if err != nil || !u.IsAbs() {
+		return (*url.URL)(nil), &FormatError{"an absolute URL, e.g. https://example.com", err}
+	}
+
+	return u, nil
+}
+
+// IPParser returns the net.IP representation of the given val.
+func IPParser(val string) (interface{}, error) {
+	ip := net.ParseIP(val)
+	if ip == nil {
+		return ip, &FormatError{"an IP address, e.g. 192.168.0.1 or ::1", nil}
+	}
+
+	return ip, nil
+}
+
+// CIDRParser returns the netip.Prefix representation of the given val.
+func CIDRParser(val string) (interface{}, error) {
+	prefix, err := netip.ParsePrefix(val)
+	if err != nil {
+		return prefix, &FormatError{"a CIDR prefix, e.g. 192.168.0.0/24", err}
+	}
+
+	return prefix, nil
+}
+
+// RegexpParser returns the compiled *regexp.Regexp of the given val.
+func RegexpParser(val string) (interface{}, error) {
+	re, err := regexp.Compile(val)
+	if err != nil {
+		return re, &FormatError{"a regular expression: " + err.Error(), err}
+	}
+
+	return re, nil
+}
+
+// ByteSizeParser returns the number of bytes as uint64 for a human readable
+// size, e.g. "512", "10MB" or "1.5GiB". Units are case insensitive, KB, MB,
+// ... are powers of 1000, KiB, MiB, ... powers of 1024.
+func ByteSizeParser(val string) (interface{}, error) {
+	num := strings.TrimRightFunc(val, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	factor, ok := byteUnits[strings.ToLower(strings.TrimSpace(val[len(num):]))]
+	size, err := byteSize(num, factor)
+	if !ok || err != nil {
+		return uint64(0), &FormatError{"a size, e.g. 512, 10MB or 1.5GiB", err}
+	}
+
+	return size, nil
+}
+
+// byteSize multiplies num by factor. Integers are computed exactly, fractions
+// as float64. Results of 2^64 or more are out of range.
+func byteSize(num string, factor uint64) (uint64, error) {
+	if !strings.Contains(num, ".") {
+		u, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		hi, lo := bits.Mul64(u, factor)
+		if hi != 0 {
+			return 0, strconv.ErrRange
+		}
+		return lo, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	// float64(math.MaxUint64) rounds up to 2^64
+	if f *= float64(factor); f < 0 || f >= math.MaxUint64 {
+		return 0, strconv.ErrRange
+	}
+	return uint64(f), nil
+}
+
+// PercentParser returns the float64 fraction of a percentage, e.g. 0.5 for
+// "50%". The percent sign is optional.
+func PercentParser(val string) (interface{}, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(val, "%")), 64)
+	if err != nil {
+		return float64(0), &FormatError{"a percentage, e.g. 50%", err}
+	}
+
+	return f / 100, nil // nolint:gomnd
+}
@@ -0,0 +1,99 @@
+package cli_test
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name       string
+		parser     cli.ParserFunc
+		val        string
+		expected   interface{}
+		shouldFail bool
+	}{
+		{name: "Int", parser: cli.IntParser, val: "-42", expected: -42},
+		{name: "IntInvalid", parser: cli.IntParser, val: "4.2", shouldFail: true},
+		{name: "Int32", parser: cli.Int32Parser, val: "42", expected: int32(42)},
+		{name: "Int32Invalid", parser: cli.Int32Parser, val: "2147483648", shouldFail: true},
+		{name: "Int64", parser: cli.Int64Parser, val: "9223372036854775807", expected: int64(9223372036854775807)},
+		{name: "Int64Invalid", parser: cli.Int64Parser, val: "test", shouldFail: true},
+		{name: "Uint", parser: cli.UintParser, val: "42", expected: uint(42)},
+		{name: "UintInvalid", parser: cli.UintParser, val: "-1", shouldFail: true},
+		{name: "Float64", parser: cli.Float64Parser, val: "4.2", expected: 4.2},
+		{name: "Float64Invalid", parser: cli.Float64Parser, val: "test", shouldFail: true},
+		{name: "BoolTrue", parser: cli.BoolParser, val: "true", expected: true},
+		{name: "BoolYes", parser: cli.BoolParser, val: "YES", expected: true},
+		{name: "BoolOne", parser: cli.BoolParser, val: "1", expected: true},
+		{name: "BoolFalse", parser: cli.BoolParser, val: "false", expected: false},
+		{name: "BoolNo", parser: cli.BoolParser, val: "no", expected: false},
+		{name: "BoolZero", parser: cli.BoolParser, val: "0", expected: false},
+		{name: "BoolInvalid", parser: cli.BoolParser, val: "test", shouldFail: true},
+		{name: "Duration", parser: cli.DurationParser, val: "1h30m", expected: 90 * time.Minute},
+		{name: "DurationInvalid", parser: cli.DurationParser, val: "10", shouldFail: true},
+		{name: "Time", parser: cli.TimeParser("2006-01-02"), val: "2022-03-04", expected: time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{name: "TimeInvalid", parser: cli.TimeParser("2006-01-02"), val: "04.03.2022", shouldFail: true},
+		{name: "URL", parser: cli.URLParser, val: "https://example.com/path", expected: &url.URL{Scheme: "https", Host: "example.com", Path: "/path"}},
+		{name: "URLRelative", parser: cli.URLParser, val: "example.com", shouldFail: true},
+		{name: "IP", parser: cli.IPParser, val: "192.168.0.1", expected: net.ParseIP("192.168.0.1")},
+		{name: "IPv6", parser: cli.IPParser, val: "::1", expected: net.IPv6loopback},
+		{name: "IPInvalid", parser: cli.IPParser, val: "192.168.0", shouldFail: true},
+		{name: "CIDR", parser: cli.CIDRParser, val: "10.0.0.0/8", expected: netip.MustParsePrefix("10.0.0.0/8")},
+		{name: "CIDRInvalid", parser: cli.CIDRParser, val: "10.0.0.0", shouldFail: true},
+		{name: "Regexp", parser: cli.RegexpParser, val: "^a+$", expected: regexp.MustCompile("^a+$")},
+		{name: "RegexpInvalid", parser: cli.RegexpParser, val: "(", shouldFail: true},
+		{name: "ByteSize", parser: cli.ByteSizeParser, val: "512", expected: uint64(512)},
+		{name: "ByteSizeMB", parser: cli.ByteSizeParser, val: "10MB", expected: uint64(10_000_000)},
+		{name: "ByteSizeMiB", parser: cli.ByteSizeParser, val: "10MiB", expected: uint64(10 << 20)},
+		{name: "ByteSizeFraction", parser: cli.ByteSizeParser, val: "1.5 gib", expected: uint64(3 << 29)},
+		{name: "ByteSizeMax", parser: cli.ByteSizeParser, val: "18446744073709551615", expected: uint64(math.MaxUint64)},
+		{name: "ByteSizeOverflow", parser: cli.ByteSizeParser, val: "18446744073709551616", shouldFail: true},
+		{name: "ByteSizeUnitOverflow", parser: cli.ByteSizeParser, val: "16384PiB", shouldFail: true},
+		{name: "ByteSizeFractionOverflow", parser: cli.ByteSizeParser, val: "16384.0PiB", shouldFail: true},
+		{name: "ByteSizeNegative", parser: cli.ByteSizeParser, val: "-1KiB", shouldFail: true},
+		{name: "ByteSizeNegativeFraction", parser: cli.ByteSizeParser, val: "-0.5MB", shouldFail: true},
+		{name: "ByteSizeInvalidUnit", parser: cli.ByteSizeParser, val: "10XB", shouldFail: true},
+		{name: "ByteSizeInvalid", parser: cli.ByteSizeParser, val: "MB", shouldFail: true},
+		{name: "Percent", parser: cli.PercentParser, val: "50%", expected: 0.5},
+		{name: "PercentWithoutSign", parser: cli.PercentParser, val: "25", expected: 0.25},
+		{name: "PercentInvalid", parser: cli.PercentParser, val: "%", shouldFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := tt.parser(tt.val)
+			if tt.shouldFail {
+				var formatErr *cli.FormatError
+				if !errors.As(err, &formatErr) || !strings.HasPrefix(err.Error(), "expected ") {
+					t.Errorf("expected a format error, got = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(tt.expected, val) {
+				t.Errorf("expected = %#v, got = %#v", tt.expected, val)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldReportExpectedFormat(t *testing.T) {
+	cmd := cli.Command{Flags: []cli.Flag{{Name: "timeout", HasValue: true, Parser: cli.DurationParser}}}
+	err := cli.NewApp(&cmd).Run(ctx, []string{"--timeout", "10"})
+	want := `invalid value "10" for --timeout: expected a duration, e.g. 1h30m or 500ms`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error = %q, got = %v", want, err)
+	}
+}
@@ -2,80 +2,124 @@ package cli
 
 import (
 	"context"
-	"fmt"
 	"os"
 )
 
-var root *Command
+const restKey = "_rest"
 
 // Map of parsed command line parameters.
 type Params map[string]interface{}
 
+// AdditionalArguments returns the arguments passed to the command, or nil if
+// the params haven't been parsed from a command line.
 func (p Params) AdditionalArguments() []string {
-	return p["_args"].([]string)
+	args, _ := p["_args"].([]string)
+	return args
 }
 
+// RemainingArguments returns the arguments after "--", or after the first
+// positional argument of a command with DisableInterspersed, that haven't been
+// assigned to a positional argument.
+func (p Params) RemainingArguments() []string {
+	rest, _ := p[restKey].([]string)
+	return rest
+}
+
+// Run executes the command tree with the process arguments, using a default App.
 func Run(ctx context.Context, cmd *Command) error {
-	root = cmd
-	cmd.Flags = append(cmd.Flags, versionFlag)
-	if cmd.Run == nil {
-		cmd.Run = rootRunner
+	return NewApp(cmd).Run(ctx, os.Args[1:])
+}
+
+// resolve parses the arguments and returns the addressed command. Once the
+// command line is parsed, the remaining params are filled for every command
+// on the path.
+func (a *App) resolve(cmd *Command, args []string, params Params) (*Command, error) {
+	parsers, err := a.parse(cmd, nil, args, params)
+	if err != nil {
+		return nil, err
 	}
 
-	args := os.Args[1:]
-	if len(args) > 0 && args[0] == "lint" {
-		errs := lint(cmd)
-		for _, err := range errs {
-			fmt.Fprintln(Err, "[WARN] "+err.Error())
-		}
-		return nil
+	cmd = parsers[len(parsers)-1].cmd
+	if cmd.showHelp {
+		return cmd, nil
 	}
 
-	params := Params{}
-	cmd, err := resolve(cmd, args, params)
+	sources, err := a.sources(parsers, params)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if len(args) == 0 || cmd.showHelp || !cmd.Runnable() {
-		cmd.printHelp()
-		return nil
+	for i, p := range parsers {
+		if err := p.finish(i == len(parsers)-1, sources); err != nil {
+			return nil, err
+		}
 	}
 
-	return cmd.Run(ctx, params)
+	return cmd, nil
 }
 
-func resolve(cmd *Command, args []string, params Params) (*Command, error) {
-	if len(args) == 1 && isHelp(args[0]) {
-		cmd.showHelp = true
-		return cmd, nil
-	}
-	p := parseArgs(cmd, &args)
-	if err := checkRequiredParams(cmd, p); err != nil {
+// parse walks the arguments and descends into the addressed sub-commands. It
+// returns a parser for each command on the path.
+func (a *App) parse(cmd *Command, path []string, args []string, params Params) ([]*parser, error) {
+	if err := cmd.bindOptions(); err != nil {
 		return nil, err
 	}
-	appendParams(params, p)
 
-	if len(args) > 0 && cmd.hasSubCommands() {
-		for _, res := range cmd.Commands {
-			if args[0] != res.Name {
-				continue
+	path = append(path, cmd.Name)
+	p := newParser(a, cmd, path, args, params)
+	if len(args) == 1 && a.isHelp(args[0]) {
+		cmd.showHelp = true
+		return []*parser{p}, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if p.terminated {
+			p.parseArgument(arg)
+			continue
+		}
+
+		if arg == "--" {
+			p.terminated = true
+			continue
+		}
+
+		if isFlag(arg) {
+			skip, err := p.parseFlag(args[i:])
+			if err != nil {
+				return nil, err
 			}
-			clone := res
-			return resolve(&clone, args[1:], params)
+			i += skip
+
+			continue
 		}
-	}
-	if len(args) > 0 {
-		fmt.Fprintf(os.Stderr, "invalid arguments: %s\n\n", args)
-		cmd.printHelp()
-		os.Exit(1)
-	}
 
-	return cmd, nil
-}
+		sub, err := p.subCommand(arg)
+		if err != nil {
+			return nil, err
+		}
+		if sub != nil {
+			if err := p.assignArguments(); err != nil {
+				return nil, err
+			}
 
-func appendParams(params Params, append Params) {
-	for key, val := range append {
-		params[key] = val
+			parsers, err := a.parse(sub, path, args[i+1:], params)
+			if err != nil {
+				return nil, err
+			}
+
+			return append([]*parser{p}, parsers...), nil
+		}
+
+		if err := p.unknownCommand(arg); err != nil {
+			return nil, err
+		}
+		p.terminated = cmd.DisableInterspersed
+		p.parseArgument(arg)
 	}
+
+	if err := p.assignArguments(); err != nil {
+		return nil, err
+	}
+
+	return []*parser{p}, nil
 }
@@ -3,7 +3,8 @@ package cli_test
 import (
 	"bytes"
 	"context"
-	"os"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -51,9 +52,9 @@ func TestRun_Version(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			out := bytes.Buffer{}
-			cli.Out = &out
-			os.Args = osArgs(tt.args.cliArgs)
-			if err := cli.Run(ctx, tt.args.cmd); err != nil {
+			app := cli.NewApp(tt.args.cmd)
+			app.Out = &out
+			if err := app.Run(ctx, tt.args.cliArgs); err != nil {
 				t.Errorf("Run() returned an error: %v", err)
 			}
 			if out.String()[:out.Len()-1] != tt.wantOutput {
@@ -85,6 +86,161 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 		args:     []string{"-i", "32"},
 		flags:    []cli.Flag{{Name: "int32", Short: "i", HasValue: true, Parser: cli.Int32Parser}},
 		expected: map[string]interface{}{"int32": int32(32)},
+	}, {
+		name:     "LongAttachedValue",
+		args:     []string{"--user=joe"},
+		flags:    []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "ShortAttachedValue",
+		args:     []string{"-ujoe"},
+		flags:    []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "ShortEqualsValue",
+		args:     []string{"-u=joe"},
+		flags:    []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "AttachedDashValue",
+		args:     []string{"--int32=-32"},
+		flags:    []cli.Flag{{Name: "int32", Short: "i", HasValue: true, Parser: cli.Int32Parser}},
+		expected: cli.Params{"int32": int32(-32)},
+	}, {
+		name:     "AttachedEmptyValue",
+		args:     []string{"--user="},
+		flags:    []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		expected: cli.Params{"user": ""},
+	}, {
+		name:       "AttachedValueWithoutHasValue",
+		args:       []string{"--user=joe"},
+		flags:      []cli.Flag{{Name: "user", Short: "u"}},
+		shouldFail: true,
+	}, {
+		name:     "ClusteredFlags",
+		args:     []string{"-abc"},
+		flags:    []cli.Flag{{Name: "a", Short: "a"}, {Name: "b", Short: "b"}, {Name: "c", Short: "c"}},
+		expected: cli.Params{"a": true, "b": true, "c": true},
+	}, {
+		name:     "ClusteredFlagsWithValue",
+		args:     []string{"-xvf", "file"},
+		flags:    []cli.Flag{{Name: "x", Short: "x"}, {Name: "v", Short: "v"}, {Name: "file", Short: "f", HasValue: true}},
+		expected: cli.Params{"x": true, "v": true, "file": "file"},
+	}, {
+		name:     "ClusteredFlagsWithAttachedValue",
+		args:     []string{"-xffile"},
+		flags:    []cli.Flag{{Name: "x", Short: "x"}, {Name: "file", Short: "f", HasValue: true}},
+		expected: cli.Params{"x": true, "file": "file"},
+	}, {
+		name:       "ClusteredUnknownFlag",
+		args:       []string{"-ab"},
+		flags:      []cli.Flag{{Name: "a", Short: "a"}},
+		shouldFail: true,
+	}, {
+		name:       "ClusteredMissingValue",
+		args:       []string{"-af"},
+		flags:      []cli.Flag{{Name: "a", Short: "a"}, {Name: "file", Short: "f", HasValue: true}},
+		shouldFail: true,
+	}, {
+		name:     "CounterClustered",
+		args:     []string{"-vvv"},
+		flags:    []cli.Flag{{Name: "verbose", Short: "v", Counter: true}},
+		expected: cli.Params{"verbose": 3},
+	}, {
+		name:     "CounterRepeated",
+		args:     []string{"-v", "--verbose", "-v"},
+		flags:    []cli.Flag{{Name: "verbose", Short: "v", Counter: true}},
+		expected: cli.Params{"verbose": 3},
+	}, {
+		name:     "RepeatedFlagLastWins",
+		args:     []string{"-u", "joe", "-u", "jane"},
+		flags:    []cli.Flag{{Name: "user", Short: "u", HasValue: true}},
+		expected: cli.Params{"user": "jane"},
+	}, {
+		name:     "BoolExplicitFalse",
+		args:     []string{"--verbose=false"},
+		flags:    []cli.Flag{{Name: "verbose", Short: "v"}},
+		expected: cli.Params{"verbose": false},
+	}, {
+		name:     "BoolExplicitTrue",
+		args:     []string{"-v=yes"},
+		flags:    []cli.Flag{{Name: "verbose", Short: "v", Default: false}},
+		expected: cli.Params{"verbose": true},
+	}, {
+		name:       "BoolInvalid",
+		args:       []string{"--verbose=test"},
+		flags:      []cli.Flag{{Name: "verbose", Short: "v"}},
+		shouldFail: true,
+	}, {
+		name:     "Negated",
+		args:     []string{"--no-color"},
+		flags:    []cli.Flag{{Name: "color", Negatable: true, Default: true}},
+		expected: cli.Params{"color": false},
+	}, {
+		name:     "NegatableDefault",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "color", Negatable: true, Default: true}},
+		expected: cli.Params{"color": true},
+	}, {
+		name:       "NotNegatable",
+		args:       []string{"--no-color"},
+		flags:      []cli.Flag{{Name: "color"}},
+		shouldFail: true,
+	}, {
+		name:       "CounterWithValue",
+		args:       []string{"--verbose=2"},
+		flags:      []cli.Flag{{Name: "verbose", Counter: true}},
+		shouldFail: true,
+	}, {
+		name:     "Repeatable",
+		args:     []string{"-t", "a", "--tag", "b", "-tc"},
+		flags:    []cli.Flag{{Name: "tag", Short: "t", HasValue: true, Repeatable: true}},
+		expected: cli.Params{"tag": []string{"a", "b", "c"}},
+	}, {
+		name:     "RepeatableTyped",
+		args:     []string{"-p", "80", "-p", "443"},
+		flags:    []cli.Flag{{Name: "port", Short: "p", HasValue: true, Repeatable: true, Parser: cli.IntParser}},
+		expected: cli.Params{"port": []int{80, 443}},
+	}, {
+		name:     "RepeatableMixedTypes",
+		args:     []string{"--x", "1", "--x", "a", "--x", "none"},
+		flags:    []cli.Flag{{Name: "x", HasValue: true, Repeatable: true, Parser: looseParser}},
+		expected: cli.Params{"x": []interface{}{1, "a", nil}},
+	}, {
+		name:     "RepeatableSeparator",
+		args:     []string{"--tags", "a,b", "--tags", "c"},
+		flags:    []cli.Flag{{Name: "tags", HasValue: true, Repeatable: true, Separator: ","}},
+		expected: cli.Params{"tags": []string{"a", "b", "c"}},
+	}, {
+		name:     "RepeatableDefault",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "tags", HasValue: true, Repeatable: true, Separator: ",", Default: "a,b"}},
+		expected: cli.Params{"tags": []string{"a", "b"}},
+	}, {
+		name:     "KeyValue",
+		args:     []string{"-e", "A=1", "-e", "B=2=3"},
+		flags:    []cli.Flag{{Name: "env", Short: "e", HasValue: true, KeyValue: true}},
+		expected: cli.Params{"env": map[string]string{"A": "1", "B": "2=3"}},
+	}, {
+		name:     "KeyValueTyped",
+		args:     []string{"--limit", "a=1,b=2"},
+		flags:    []cli.Flag{{Name: "limit", HasValue: true, KeyValue: true, Separator: ",", Parser: cli.IntParser}},
+		expected: cli.Params{"limit": map[string]int{"a": 1, "b": 2}},
+	}, {
+		name:     "KeyValueMixedTypes",
+		args:     []string{"-e", "a=1", "-e", "b=none", "-e", "c=x"},
+		flags:    []cli.Flag{{Name: "env", Short: "e", HasValue: true, KeyValue: true, Parser: looseParser}},
+		expected: cli.Params{"env": map[string]interface{}{"a": 1, "b": nil, "c": "x"}},
+	}, {
+		name:     "KeyValueNilValue",
+		args:     []string{"-e", "a=none", "-e", "b=1"},
+		flags:    []cli.Flag{{Name: "env", Short: "e", HasValue: true, KeyValue: true, Parser: looseParser}},
+		expected: cli.Params{"env": map[string]interface{}{"a": nil, "b": 1}},
+	}, {
+		name:       "KeyValueInvalid",
+		args:       []string{"-e", "A"},
+		flags:      []cli.Flag{{Name: "env", Short: "e", HasValue: true, KeyValue: true}},
+		shouldFail: true,
 	}, {
 		name:     "TwoFlags",
 		args:     []string{"-i", "-s"},
@@ -94,12 +250,52 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 		name:     "FlagUnset",
 		args:     []string{},
 		flags:    []cli.Flag{{Name: "i", Short: "i"}},
-		expected: map[string]interface{}{},
+		expected: map[string]interface{}{"i": false},
 	}, {
 		name:       "RequiredMissing",
 		args:       []string{},
 		flags:      []cli.Flag{{Name: "i", Short: "i", Required: true}},
 		shouldFail: true,
+	}, {
+		name:     "FlagDefault",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, Default: "joe"}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "FlagDefaultOverridden",
+		args:     []string{"--user", "jane"},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, Default: "joe"}},
+		expected: cli.Params{"user": "jane"},
+	}, {
+		name:     "FlagDefaultParsed",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "int32", HasValue: true, Parser: cli.Int32Parser, Default: "32"}},
+		expected: cli.Params{"int32": int32(32)},
+	}, {
+		name:       "FlagDefaultInvalid",
+		args:       []string{},
+		flags:      []cli.Flag{{Name: "int32", HasValue: true, Parser: cli.Int32Parser, Default: "test"}},
+		shouldFail: true,
+	}, {
+		name:     "BoolDefaultParsed",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "color", Negatable: true, Default: "true"}},
+		expected: cli.Params{"color": true},
+	}, {
+		name:     "CounterDefaultParsed",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "verbose", Counter: true, Default: "2"}},
+		expected: cli.Params{"verbose": 2},
+	}, {
+		name:     "RequiredWithDefault",
+		args:     []string{},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, Required: true, Default: "joe"}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:      "ArgDefaultParsed",
+		args:      []string{},
+		arguments: []cli.Arg{{Name: "int32", Parser: cli.Int32Parser, Default: "32"}},
+		expected:  cli.Params{"int32": int32(32)},
 	}, {
 		name:      "StringValue",
 		args:      []string{"test"},
@@ -111,10 +307,10 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 		arguments: []cli.Arg{{Name: "int32", Parser: cli.Int32Parser}},
 		expected:  cli.Params{"int32": int32(32)},
 	}, {
-		name:      "Int32InvalidValue",
-		args:      []string{"test"},
-		arguments: []cli.Arg{{Name: "int32", Parser: cli.Int32Parser}},
-		expected:  cli.Params{"int32": int32(-1)},
+		name:       "Int32InvalidValue",
+		args:       []string{"test"},
+		arguments:  []cli.Arg{{Name: "int32", Parser: cli.Int32Parser}},
+		shouldFail: true,
 	}, {
 		name:      "TwoArgs",
 		args:      []string{"test1", "test2"},
@@ -153,10 +349,64 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 		arguments: []cli.Arg{{Name: "required", Required: true, Vararg: true}},
 		flags:     []cli.Flag{{Name: "p", Short: "p", HasValue: true}},
 		expected:  cli.Params{"required": []string{"test1", "test2"}, "p": "test"},
+	}, {
+		name:      "TypedVarargs",
+		args:      []string{"1", "2"},
+		arguments: []cli.Arg{{Name: "ints", Parser: cli.IntParser, Vararg: true}},
+		expected:  cli.Params{"ints": []int{1, 2}},
+	}, {
+		name:      "VarargsMixedTypes",
+		args:      []string{"1", "a"},
+		arguments: []cli.Arg{{Name: "values", Parser: looseParser, Vararg: true}},
+		expected:  cli.Params{"values": []interface{}{1, "a"}},
+	}, {
+		name:      "VarargsNilValue",
+		args:      []string{"none", "1"},
+		arguments: []cli.Arg{{Name: "values", Parser: looseParser, Vararg: true}},
+		expected:  cli.Params{"values": []interface{}{nil, 1}},
+	}, {
+		name:       "TypedVarargsInvalidValue",
+		args:       []string{"1", "test"},
+		arguments:  []cli.Arg{{Name: "ints", Parser: cli.IntParser, Vararg: true}},
+		shouldFail: true,
+	}, {
+		name:      "VarargsAndTrailingArg",
+		args:      []string{"a", "b", "dir"},
+		arguments: []cli.Arg{{Name: "src", Vararg: true}, {Name: "dst"}},
+		expected:  cli.Params{"src": []string{"a", "b"}, "dst": "dir"},
+	}, {
+		name:      "VarargsBetweenArgs",
+		args:      []string{"1", "a", "dir"},
+		arguments: []cli.Arg{{Name: "mode", Parser: cli.IntParser}, {Name: "src", Vararg: true}, {Name: "dst"}},
+		expected:  cli.Params{"mode": 1, "src": []string{"a"}, "dst": "dir"},
+	}, {
+		name:      "VarargsTrailingArgFirst",
+		args:      []string{"dir"},
+		arguments: []cli.Arg{{Name: "src", Vararg: true}, {Name: "dst"}},
+		expected:  cli.Params{"dst": "dir"},
+	}, {
+		name:      "VarargsMin",
+		args:      []string{"a", "b"},
+		arguments: []cli.Arg{{Name: "files", Vararg: true, Min: 2, Max: 3}},
+		expected:  cli.Params{"files": []string{"a", "b"}},
+	}, {
+		name:       "VarargsBelowMin",
+		args:       []string{"a"},
+		arguments:  []cli.Arg{{Name: "files", Vararg: true, Min: 2}},
+		shouldFail: true,
+	}, {
+		name:       "VarargsAboveMax",
+		args:       []string{"a", "b", "c"},
+		arguments:  []cli.Arg{{Name: "files", Vararg: true, Max: 2}},
+		shouldFail: true,
+	}, {
+		name:      "VarargsDefault",
+		args:      []string{},
+		arguments: []cli.Arg{{Name: "ints", Parser: cli.IntParser, Vararg: true, Default: []string{"1", "2"}}},
+		expected:  cli.Params{"ints": []int{1, 2}},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			os.Args = osArgs(tt.args)
 			run := func(_ context.Context, params cli.Params) error {
 				delete(params, "_args")
 				if equal := reflect.DeepEqual(tt.expected, params); !equal {
@@ -165,13 +415,12 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 				return nil
 			}
 			cmd := &cli.Command{Flags: tt.flags, Args: tt.arguments, Run: run}
-			if err := cli.Run(ctx, cmd); (err != nil) != tt.shouldFail {
+			if err := cli.NewApp(cmd).Run(ctx, tt.args); (err != nil) != tt.shouldFail {
 				t.Errorf("unexpected error %v", err)
 			}
-			os.Args = append([]string{"cmd"}, osArgs(tt.args)...)
 			subCmd := cli.Command{Name: "cmd", Flags: tt.flags, Args: tt.arguments, Run: run}
 			cmd = &cli.Command{Commands: []cli.Command{subCmd}}
-			if err := cli.Run(ctx, cmd); (err != nil) != tt.shouldFail {
+			if err := cli.NewApp(cmd).Run(ctx, append([]string{"cmd"}, tt.args...)); (err != nil) != tt.shouldFail {
 				t.Errorf("unexpected error %v", err)
 			}
 		})
@@ -180,10 +429,10 @@ func TestRun_ShouldParseFlag(t *testing.T) {
 
 func TestRun_ShouldLint(t *testing.T) {
 	cmd := cli.Command{}
-	os.Args = []string{"cmd", "lint"}
 	buf := bytes.Buffer{}
-	cli.Err = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Err = &buf
+	if err := app.Run(ctx, []string{"lint"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "WARN") {
@@ -191,12 +440,70 @@ func TestRun_ShouldLint(t *testing.T) {
 	}
 }
 
+func TestRun_ShouldLintDefaults(t *testing.T) {
+	cmd := cli.Command{Name: "cmd", Flags: []cli.Flag{
+		{Name: "valid", Description: "desc", HasValue: true, Parser: cli.Int32Parser, Default: int32(1)},
+		{Name: "type", Description: "desc", HasValue: true, Parser: cli.Int32Parser, Default: 1},
+		{Name: "string", Description: "desc", HasValue: true, Parser: cli.Int32Parser, Default: "test"},
+		{Name: "bool", Description: "desc", Default: "maybe"},
+		{Name: "negatable", Description: "desc", Negatable: true, Default: "true"},
+		{Name: "counter", Description: "desc", Counter: true, Default: "2"},
+		{Name: "tags", Description: "desc", HasValue: true, Repeatable: true, Default: []string{"a", "b"}},
+		{Name: "ports", Description: "desc", HasValue: true, Repeatable: true, Separator: ",", Parser: cli.IntParser, Default: "80,443"},
+		{Name: "labels", Description: "desc", HasValue: true, KeyValue: true, Default: map[string]string{"a": "1"}},
+		{Name: "limits", Description: "desc", HasValue: true, KeyValue: true, Parser: cli.IntParser, Default: map[string]int{"a": 1}},
+		{Name: "slice", Description: "desc", HasValue: true, Repeatable: true, Parser: cli.IntParser, Default: []string{"80"}},
+		{Name: "map", Description: "desc", HasValue: true, KeyValue: true, Default: []string{"a=1"}},
+	}, Args: []cli.Arg{{Name: "arg", Parser: cli.Int32Parser, Default: int64(1)}}}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Err = &buf
+	if err := app.Run(ctx, []string{"lint"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for _, name := range []string{"param type", "param string", "param bool", "param slice", "param map", "argument arg"} {
+		if !strings.Contains(buf.String(), "invalid default on "+name+" ") {
+			t.Errorf("Output doesn't contain a warning for %s", name)
+		}
+	}
+	for _, name := range []string{"valid", "negatable", "counter", "tags", "ports", "labels", "limits"} {
+		if strings.Contains(buf.String(), "param "+name+" ") {
+			t.Errorf("Output contains a warning for the valid default of %s", name)
+		}
+	}
+}
+
+func TestRun_ShouldLintConstraints(t *testing.T) {
+	sub := cli.Command{
+		Name: "sub", Short: "sub",
+		Constraints: []cli.Constraint{cli.MutuallyExclusive("json", "yaml"), cli.OneRequired("json", "unknown")},
+	}
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{sub}, Flags: []cli.Flag{
+		{Name: "json", Description: "desc", Persistent: true},
+		{Name: "yaml", Description: "desc", Persistent: true},
+	}}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Err = &buf
+	if err := app.Run(ctx, []string{"lint"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "unknown flag unknown in constraint of command sub") {
+		t.Errorf("Output doesn't contain a warning for the unknown flag")
+	}
+	for _, name := range []string{"json", "yaml"} {
+		if strings.Contains(buf.String(), "unknown flag "+name+" ") {
+			t.Errorf("Output contains a warning for the inherited flag %s", name)
+		}
+	}
+}
+
 func TestRun_ShouldPrintHelpUsage(t *testing.T) {
 	cmd := cli.Command{Name: "cmd"}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "Usage:\n  cmd") {
@@ -208,10 +515,10 @@ func TestRun_ShouldPrintHelpSubCommand(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Commands: []cli.Command{{
 		Group: "group-name", Name: "sub-cmd", Short: "description text",
 	}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "sub-cmd") {
@@ -224,10 +531,10 @@ func TestRun_ShouldPrintHelpSubCommand(t *testing.T) {
 
 func TestRun_ShouldPrintHelpCommandGroup(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Commands: []cli.Command{{Group: "group-name", Name: "sub-cmd"}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "group-name:") {
@@ -237,10 +544,10 @@ func TestRun_ShouldPrintHelpCommandGroup(t *testing.T) {
 
 func TestRun_ShouldPrintHelpArguments(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Args: []cli.Arg{{Name: "arg1"}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "Arguments:") {
@@ -250,10 +557,10 @@ func TestRun_ShouldPrintHelpArguments(t *testing.T) {
 
 func TestRun_ShouldPrintHelpArgumentLine(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Args: []cli.Arg{{Name: "arg1", Description: "desc1"}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "arg1") {
@@ -264,12 +571,19 @@ func TestRun_ShouldPrintHelpArgumentLine(t *testing.T) {
 	}
 }
 
+func TestRun_ShouldPrintHelpVarargs(t *testing.T) {
+	cmd := cli.Command{Name: "cp", Args: []cli.Arg{{Name: "src", Vararg: true}, {Name: "dst"}}}
+	if usage := cmd.Usage(); usage != "cp <src>... <dst>" {
+		t.Errorf("expected usage = %q, got = %q", "cp <src>... <dst>", usage)
+	}
+}
+
 func TestRun_ShouldPrintHelpFlags(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Args: []cli.Arg{{Name: "arg1"}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "Flags:") {
@@ -279,10 +593,10 @@ func TestRun_ShouldPrintHelpFlags(t *testing.T) {
 
 func TestRun_ShouldPrintHelpFlagLine(t *testing.T) {
 	cmd := cli.Command{Name: "cmd", Flags: []cli.Flag{{Name: "flag1", Description: "desc1"}}}
-	os.Args = []string{"cmd", "help"}
 	buf := bytes.Buffer{}
-	cli.Out = &buf
-	if err := cli.Run(ctx, &cmd); err != nil {
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !strings.Contains(buf.String(), "flag1") {
@@ -303,8 +617,7 @@ func TestRun_ShouldExecCommand(t *testing.T) {
 		},
 	}
 	cmd := cli.Command{Commands: []cli.Command{res}}
-	os.Args = []string{"cmd", "exec"}
-	if err := cli.Run(ctx, &cmd); err != nil {
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"exec"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !ran {
@@ -325,8 +638,7 @@ func TestRun_ShouldExecSecondCommand(t *testing.T) {
 		},
 	}
 	cmd := cli.Command{Commands: []cli.Command{first, res}}
-	os.Args = []string{"cmd", "exec"}
-	if err := cli.Run(ctx, &cmd); err != nil {
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"exec"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !ran {
@@ -345,11 +657,557 @@ func TestRun_ShouldExecSubCommand(t *testing.T) {
 	}
 	res := cli.Command{Name: "exec", Commands: []cli.Command{subres}}
 	cmd := cli.Command{Commands: []cli.Command{res}}
-	os.Args = []string{"cmd", "exec", "sub"}
-	if err := cli.Run(ctx, &cmd); err != nil {
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"exec", "sub"}); err != nil {
 		t.Errorf("Unexpected error %v", err)
 	}
 	if !ran {
 		t.Error("Handler not executed")
 	}
 }
+
+func TestRun_ShouldStopFlagParsingAtTerminator(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		flags        []cli.Flag
+		arguments    []cli.Arg
+		expected     cli.Params
+		expectedRest []string
+	}{{
+		name:      "NegativeNumber",
+		args:      []string{"--", "-32"},
+		arguments: []cli.Arg{{Name: "int32", Parser: cli.Int32Parser}},
+		expected:  cli.Params{"int32": int32(-32)},
+	}, {
+		name:      "DashFile",
+		args:      []string{"-v", "--", "-foo.txt"},
+		flags:     []cli.Flag{{Name: "verbose", Short: "v"}},
+		arguments: []cli.Arg{{Name: "file"}},
+		expected:  cli.Params{"file": "-foo.txt", "verbose": true},
+	}, {
+		name:      "Stdin",
+		args:      []string{"-"},
+		arguments: []cli.Arg{{Name: "file"}},
+		expected:  cli.Params{"file": "-"},
+	}, {
+		name:         "RemainingArguments",
+		args:         []string{"file", "--", "-la", "--", "dir"},
+		arguments:    []cli.Arg{{Name: "file"}},
+		expected:     cli.Params{"file": "file"},
+		expectedRest: []string{"-la", "--", "dir"},
+	}, {
+		name:      "Vararg",
+		args:      []string{"a", "--", "-b", "c"},
+		arguments: []cli.Arg{{Name: "files", Vararg: true}},
+		expected:  cli.Params{"files": []string{"a", "-b", "c"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			sub := cli.Command{Name: "exec", Flags: tt.flags, Args: tt.arguments, Run: func(_ context.Context, params cli.Params) error {
+				ran = true
+				if rest := params.RemainingArguments(); !reflect.DeepEqual(tt.expectedRest, rest) {
+					t.Errorf("expected rest = %+v, got = %+v", tt.expectedRest, rest)
+				}
+				delete(params, "_args")
+				delete(params, "_rest")
+				if !reflect.DeepEqual(tt.expected, params) {
+					t.Errorf("expected = %+v, got = %+v", tt.expected, params)
+				}
+				return nil
+			}}
+			cmd := cli.Command{Commands: []cli.Command{sub}}
+			if err := cli.NewApp(&cmd).Run(ctx, append([]string{"exec"}, tt.args...)); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if !ran {
+				t.Error("Handler not executed")
+			}
+		})
+	}
+}
+
+func TestRun_ShouldStopFlagParsingAtFirstArgument(t *testing.T) {
+	var got cli.Params
+	sub := cli.Command{
+		Name:                "exec",
+		DisableInterspersed: true,
+		Flags:               []cli.Flag{{Name: "verbose", Short: "v"}},
+		Args:                []cli.Arg{{Name: "program"}},
+		Run: func(_ context.Context, params cli.Params) error {
+			got = params
+			return nil
+		},
+	}
+	cmd := cli.Command{Commands: []cli.Command{sub}}
+	args := []string{"exec", "-v", "ls", "-la", "-v", "dir"}
+	if err := cli.NewApp(&cmd).Run(ctx, args); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if got["program"] != "ls" || got["verbose"] != true {
+		t.Errorf("unexpected params %+v", got)
+	}
+	if rest := got.RemainingArguments(); !reflect.DeepEqual(rest, []string{"-la", "-v", "dir"}) {
+		t.Errorf("unexpected remaining arguments %+v", rest)
+	}
+}
+
+func TestRun_ShouldInheritPersistentFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		expected   cli.Params
+		shouldFail bool
+	}{{
+		name:     "BeforeSubCommand",
+		args:     []string{"--config", "file", "exec", "sub"},
+		expected: cli.Params{"config": "file", "local": false},
+	}, {
+		name:     "AfterSubCommand",
+		args:     []string{"exec", "sub", "--config", "file"},
+		expected: cli.Params{"config": "file", "local": false},
+	}, {
+		name:     "Counter",
+		args:     []string{"exec", "-d", "sub", "-dd", "-c", "file"},
+		expected: cli.Params{"config": "file", "debug": 3, "local": false},
+	}, {
+		name:       "RequiredMissing",
+		args:       []string{"exec", "sub"},
+		shouldFail: true,
+	}, {
+		name:       "NotPersistent",
+		args:       []string{"exec", "sub", "--config", "file", "--local"},
+		shouldFail: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := cli.Command{Name: "sub", Run: func(_ context.Context, params cli.Params) error {
+				delete(params, "_args")
+				if !reflect.DeepEqual(tt.expected, params) {
+					t.Errorf("expected = %+v, got = %+v", tt.expected, params)
+				}
+				return nil
+			}}
+			exec := cli.Command{Name: "exec", Commands: []cli.Command{sub}, Flags: []cli.Flag{
+				{Name: "debug", Short: "d", Counter: true, Persistent: true},
+			}}
+			cmd := cli.Command{Commands: []cli.Command{exec}, Flags: []cli.Flag{
+				{Name: "config", Short: "c", HasValue: true, Required: true, Persistent: true},
+				{Name: "local"},
+			}}
+			if err := cli.NewApp(&cmd).Run(ctx, tt.args); (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldPrintHelpGlobalFlags(t *testing.T) {
+	cmd := cli.Command{
+		Name:     "cmd",
+		Flags:    []cli.Flag{{Name: "config", Description: "desc1", Persistent: true}},
+		Commands: []cli.Command{{Name: "sub-cmd"}},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"sub-cmd", "help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "Global Flags:\n  --config:     desc1") {
+		t.Errorf("Output doesn't contain the global flags section")
+	}
+}
+
+func TestRun_ShouldReadEnvironment(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		prefix     string
+		flags      []cli.Flag
+		arguments  []cli.Arg
+		expected   cli.Params
+		shouldFail bool
+	}{{
+		name:     "FlagEnvVar",
+		env:      map[string]string{"USER_NAME": "joe"},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, EnvVar: "USER_NAME"}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "CommandLineFirst",
+		args:     []string{"--user", "jane"},
+		env:      map[string]string{"USER_NAME": "joe"},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, EnvVar: "USER_NAME"}},
+		expected: cli.Params{"user": "jane"},
+	}, {
+		name:     "BeforeDefault",
+		env:      map[string]string{"USER_NAME": "joe"},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, EnvVar: "USER_NAME", Default: "jane"}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:     "Prefix",
+		env:      map[string]string{"MYAPP_USER_NAME": "joe", "MYAPP_VERSION": "true"},
+		prefix:   "MYAPP",
+		flags:    []cli.Flag{{Name: "user-name", HasValue: true}},
+		expected: cli.Params{"user-name": "joe"},
+	}, {
+		name:     "Parsed",
+		env:      map[string]string{"MYAPP_INT32": "32", "MYAPP_BOOL": "true", "MYAPP_COUNT": "2"},
+		prefix:   "MYAPP_",
+		flags:    []cli.Flag{{Name: "int32", HasValue: true, Parser: cli.Int32Parser}, {Name: "bool"}, {Name: "count", Counter: true}},
+		expected: cli.Params{"int32": int32(32), "bool": true, "count": 2},
+	}, {
+		name:       "Invalid",
+		env:        map[string]string{"INT": "test"},
+		flags:      []cli.Flag{{Name: "int32", HasValue: true, Parser: cli.Int32Parser, EnvVar: "INT"}},
+		shouldFail: true,
+	}, {
+		name:     "Required",
+		env:      map[string]string{"USER_NAME": "joe"},
+		flags:    []cli.Flag{{Name: "user", HasValue: true, EnvVar: "USER_NAME", Required: true}},
+		expected: cli.Params{"user": "joe"},
+	}, {
+		name:      "Arg",
+		env:       map[string]string{"FILE": "test.txt"},
+		arguments: []cli.Arg{{Name: "file", EnvVar: "FILE", Required: true}},
+		expected:  cli.Params{"file": "test.txt"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			sub := cli.Command{Name: "exec", Flags: tt.flags, Args: tt.arguments, Run: func(_ context.Context, params cli.Params) error {
+				ran = true
+				delete(params, "_args")
+				if !reflect.DeepEqual(tt.expected, params) {
+					t.Errorf("expected = %+v, got = %+v", tt.expected, params)
+				}
+				return nil
+			}}
+			app := cli.NewApp(&cli.Command{Commands: []cli.Command{sub}})
+			app.EnvPrefix = tt.prefix
+			app.LookupEnv = func(key string) (string, bool) {
+				val, ok := tt.env[key]
+				return val, ok
+			}
+			if err := app.Run(ctx, append([]string{"exec"}, tt.args...)); (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !ran && !tt.shouldFail {
+				t.Error("Handler not executed")
+			}
+		})
+	}
+}
+
+func TestRun_ShouldPrintHelpEnvVars(t *testing.T) {
+	cmd := cli.Command{
+		Name:  "cmd",
+		Flags: []cli.Flag{{Name: "user-name", Description: "desc1"}, {Name: "pass", Description: "desc2", EnvVar: "PASS"}},
+		Args:  []cli.Arg{{Name: "file", Description: "desc3", EnvVar: "FILE"}},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	app.EnvPrefix = "app"
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for _, want := range []string{"desc1 [$APP_USER_NAME]", "desc2 [$PASS]", "desc3 [$FILE]"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Output doesn't contain %q", want)
+		}
+	}
+	if strings.Contains(buf.String(), "APP_VERSION") {
+		t.Errorf("Output contains an environment variable for the version flag")
+	}
+}
+
+func TestRun_ShouldValidateChoices(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		flags      []cli.Flag
+		arguments  []cli.Arg
+		expected   cli.Params
+		shouldFail bool
+	}{{
+		name:     "Flag",
+		args:     []string{"--output", "yaml"},
+		flags:    []cli.Flag{{Name: "output", HasValue: true, Choices: []string{"json", "yaml"}}},
+		expected: cli.Params{"output": "yaml"},
+	}, {
+		name:       "FlagInvalid",
+		args:       []string{"--output", "xml"},
+		flags:      []cli.Flag{{Name: "output", HasValue: true, Choices: []string{"json", "yaml"}}},
+		shouldFail: true,
+	}, {
+		name:       "FlagCaseSensitive",
+		args:       []string{"--output", "YAML"},
+		flags:      []cli.Flag{{Name: "output", HasValue: true, Choices: []string{"json", "yaml"}}},
+		shouldFail: true,
+	}, {
+		name:     "FlagIgnoreCase",
+		args:     []string{"--output", "YAML"},
+		flags:    []cli.Flag{{Name: "output", HasValue: true, Choices: []string{"json", "yaml"}, IgnoreCase: true}},
+		expected: cli.Params{"output": "yaml"},
+	}, {
+		name:     "FlagParsed",
+		args:     []string{"--level", "2"},
+		flags:    []cli.Flag{{Name: "level", HasValue: true, Choices: []string{"1", "2"}, Parser: cli.IntParser}},
+		expected: cli.Params{"level": 2},
+	}, {
+		name:       "Env",
+		env:        map[string]string{"OUTPUT": "xml"},
+		flags:      []cli.Flag{{Name: "output", HasValue: true, Choices: []string{"json", "yaml"}, EnvVar: "OUTPUT"}},
+		shouldFail: true,
+	}, {
+		name:      "Arg",
+		args:      []string{"json"},
+		arguments: []cli.Arg{{Name: "output", Choices: []string{"json", "yaml"}}},
+		expected:  cli.Params{"output": "json"},
+	}, {
+		name:       "ArgInvalid",
+		args:       []string{"xml"},
+		arguments:  []cli.Arg{{Name: "output", Choices: []string{"json", "yaml"}}},
+		shouldFail: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := cli.Command{Name: "exec", Flags: tt.flags, Args: tt.arguments, Run: func(_ context.Context, params cli.Params) error {
+				delete(params, "_args")
+				if !reflect.DeepEqual(tt.expected, params) {
+					t.Errorf("expected = %+v, got = %+v", tt.expected, params)
+				}
+				return nil
+			}}
+			app := cli.NewApp(&cli.Command{Commands: []cli.Command{sub}})
+			app.LookupEnv = func(key string) (string, bool) {
+				val, ok := tt.env[key]
+				return val, ok
+			}
+			err := app.Run(ctx, append([]string{"exec"}, tt.args...))
+			if (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "expected one of json, yaml") {
+				t.Errorf("error doesn't list the choices: %v", err)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldPrintHelpNegatable(t *testing.T) {
+	cmd := cli.Command{
+		Name:  "cmd",
+		Flags: []cli.Flag{{Name: "color", Short: "c", Description: "desc1", Negatable: true}},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "-c,--[no-]color:     desc1") {
+		t.Errorf("Output doesn't contain the negatable flag")
+	}
+}
+
+func TestRun_ShouldPrintHelpRepeatable(t *testing.T) {
+	cmd := cli.Command{
+		Name:  "cmd",
+		Flags: []cli.Flag{{Name: "env", Description: "desc1", HasValue: true, KeyValue: true}},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "desc1 (repeatable)") {
+		t.Errorf("Output doesn't mark the flag as repeatable")
+	}
+}
+
+func TestRun_ShouldPrintHelpChoices(t *testing.T) {
+	cmd := cli.Command{
+		Name:  "cmd",
+		Flags: []cli.Flag{{Name: "output", Description: "desc1", HasValue: true, Choices: []string{"json", "yaml", "table"}}},
+		Args:  []cli.Arg{{Name: "format", Description: "desc2", Choices: []string{"a", "b"}}},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for _, want := range []string{"desc1 (json|yaml|table)", "desc2 (a|b)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Output doesn't contain %q", want)
+		}
+	}
+}
+
+func TestRun_ShouldMatchAliasesAndPrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		prefix    bool
+		expected  string
+		ambiguous bool
+	}{
+		{name: "Name", args: []string{"list"}, expected: "list"},
+		{name: "Alias", args: []string{"ls"}, expected: "list"},
+		{name: "PrefixDisabled", args: []string{"logi"}},
+		{name: "Prefix", args: []string{"logi"}, prefix: true, expected: "login"},
+		{name: "AliasPrefix", args: []string{"li"}, prefix: true, expected: "list"},
+		{name: "ExactBeforePrefix", args: []string{"log"}, prefix: true, expected: "log"},
+		{name: "AmbiguousPrefix", args: []string{"lo"}, prefix: true, ambiguous: true},
+		{name: "FlagPrefix", args: []string{"login", "--us", "joe", "--verb"}, prefix: true, expected: "login"},
+		{name: "FlagPrefixDisabled", args: []string{"login", "--verb"}},
+		{name: "AmbiguousFlagPrefix", args: []string{"login", "--v"}, prefix: true, ambiguous: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := ""
+			runner := func(name string) cli.Runner {
+				return func(_ context.Context, params cli.Params) error {
+					ran = name
+					if name == "login" && len(tt.args) > 1 && (params["user"] != "joe" || params["verbose"] != true) {
+						t.Errorf("unexpected params %+v", params)
+					}
+					return nil
+				}
+			}
+			cmd := cli.Command{Name: "app", Commands: []cli.Command{
+				{Name: "list", Aliases: []string{"ls", "lst"}, Run: runner("list")},
+				{Name: "login", Run: runner("login"), Flags: []cli.Flag{
+					{Name: "user", HasValue: true}, {Name: "verbose"}, {Name: "version-check"},
+				}},
+				{Name: "log", Run: runner("log")},
+			}}
+			app := cli.NewApp(&cmd)
+			app.PrefixMatching = tt.prefix
+			err := app.Run(ctx, tt.args)
+			var ambiguousErr *cli.AmbiguousError
+			if errors.As(err, &ambiguousErr) != tt.ambiguous {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.expected != "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if ran != tt.expected {
+				t.Errorf("expected command %q, got %q", tt.expected, ran)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldListAmbiguousCandidates(t *testing.T) {
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{{Name: "login"}, {Name: "logout"}}}
+	app := cli.NewApp(&cmd)
+	app.PrefixMatching = true
+	err := app.Run(ctx, []string{"log"})
+	if want := `ambiguous command "log", could be login, logout`; err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got = %v", want, err)
+	}
+}
+
+func TestRun_ShouldPrintHelpAliases(t *testing.T) {
+	cmd := cli.Command{Name: "cmd", Commands: []cli.Command{{Name: "ls", Aliases: []string{"list"}, Short: "desc1"}}}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "ls, list      desc1") {
+		t.Errorf("Output doesn't list the aliases")
+	}
+}
+
+func TestRun_ShouldExecuteHooks(t *testing.T) {
+	errRun := errors.New("run failed")
+	tests := []struct {
+		name     string
+		runErr   error
+		expected []string
+	}{{
+		name: "Success",
+		expected: []string{
+			"root.persistentPreRun", "sub.persistentPreRun", "cmd.persistentPreRun", "cmd.preRun", "cmd.run",
+			"cmd.postRun <nil>", "cmd.persistentPostRun <nil>", "sub.persistentPostRun <nil>", "root.persistentPostRun <nil>",
+		},
+	}, {
+		name:   "RunFailed",
+		runErr: errRun,
+		expected: []string{
+			"root.persistentPreRun", "sub.persistentPreRun", "cmd.persistentPreRun", "cmd.preRun", "cmd.run",
+			"cmd.postRun run failed", "cmd.persistentPostRun run failed", "sub.persistentPostRun run failed",
+			"root.persistentPostRun run failed",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]string, 0)
+			hook := func(name string) cli.Runner {
+				return func(ctx context.Context, _ cli.Params) error {
+					if strings.HasSuffix(strings.ToLower(name), "postrun") {
+						name = fmt.Sprintf("%s %v", name, cli.RunErrorFromContext(ctx))
+					}
+					calls = append(calls, name)
+					return nil
+				}
+			}
+			hooks := func(name string) cli.Command {
+				return cli.Command{
+					Name: name, PersistentPreRun: hook(name + ".persistentPreRun"),
+					PersistentPostRun: hook(name + ".persistentPostRun"),
+				}
+			}
+			cmd := hooks("cmd")
+			cmd.PreRun, cmd.PostRun = hook("cmd.preRun"), hook("cmd.postRun")
+			cmd.Run = func(_ context.Context, _ cli.Params) error {
+				calls = append(calls, "cmd.run")
+				return tt.runErr
+			}
+			sub := hooks("sub")
+			sub.Commands = []cli.Command{cmd}
+			root := hooks("root")
+			root.Commands = []cli.Command{sub}
+			if err := cli.NewApp(&root).Run(ctx, []string{"sub", "cmd"}); !errors.Is(err, tt.runErr) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(tt.expected, calls) {
+				t.Errorf("expected = %+v, got = %+v", tt.expected, calls)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldSkipRunIfPreRunFails(t *testing.T) {
+	errPreRun := errors.New("pre run failed")
+	ran, postRan := false, false
+	cmd := cli.Command{
+		Name:   "cmd",
+		PreRun: func(_ context.Context, _ cli.Params) error { return errPreRun },
+		Run: func(_ context.Context, _ cli.Params) error {
+			ran = true
+			return nil
+		},
+		PostRun: func(ctx context.Context, _ cli.Params) error {
+			postRan = cli.RunErrorFromContext(ctx) == errPreRun
+			return nil
+		},
+	}
+	if err := cli.NewApp(&cli.Command{Commands: []cli.Command{cmd}}).Run(ctx, []string{"cmd"}); err != errPreRun {
+		t.Errorf("unexpected error %v", err)
+	}
+	if ran {
+		t.Error("Handler executed")
+	}
+	if !postRan {
+		t.Error("Post hook not executed with the error")
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// OnShutdown adds hooks that run after the Runner returns, in reverse order.
+// Their context isn't cancelled by signals, so they can clean up after an
+// interrupted command.
+func (a *App) OnShutdown(hooks ...func(ctx context.Context) error) {
+	a.shutdownHooks = append(a.shutdownHooks, hooks...)
+}
+
+// shutdown runs the shutdown hooks. The first error is returned, starting
+// with the given error of the Runner.
+func (a *App) shutdown(ctx context.Context, err error) error {
+	for i := len(a.shutdownHooks) - 1; i >= 0; i-- {
+		if hookErr := a.shutdownHooks[i](ctx); err == nil {
+			err = hookErr
+		}
+	}
+	return err
+}
+
+// notifySignals returns a context cancelled on SIGINT or SIGTERM, if
+// HandleSignals is enabled. The process exits after a second signal, or once
+// the GracePeriod is over. The returned function stops the signal handling.
+func (a *App) notifySignals(ctx context.Context) (context.Context, func()) {
+	if !a.HandleSignals {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 2) // nolint:gomnd
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		var grace <-chan time.Time
+		if a.GracePeriod > 0 {
+			timer := time.NewTimer(a.GracePeriod)
+			defer timer.Stop()
+			grace = timer.C
+		}
+
+		select {
+		case <-signals:
+			a.exit(ExitCodeInterrupted)
+		case <-grace:
+			a.exit(ExitCodeInterrupted)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
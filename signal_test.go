@@ -0,0 +1,105 @@
+package cli_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joewhite86/cli"
+)
+
+func interrupt(t *testing.T) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("Can't send signals: %v", err)
+	}
+}
+
+func TestApp_ShouldCancelContextOnSignal(t *testing.T) {
+	tests := []struct {
+		name        string
+		signals     int
+		gracePeriod time.Duration
+		wait        time.Duration
+		wantExit    bool
+	}{
+		{name: "Cancel", signals: 1},
+		{name: "SecondSignal", signals: 2, wait: time.Second, wantExit: true},
+		{name: "GracePeriod", signals: 1, gracePeriod: 10 * time.Millisecond, wait: time.Second, wantExit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exited, code := make(chan struct{}), 0
+			cmd := cli.Command{Name: "cmd", Run: func(ctx context.Context, _ cli.Params) error {
+				interrupt(t)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+					t.Error("Context not cancelled")
+				}
+				for i := 1; i < tt.signals; i++ {
+					interrupt(t)
+				}
+				select {
+				case <-exited:
+				case <-time.After(tt.wait):
+				}
+				return ctx.Err()
+			}}
+			app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{cmd}})
+			app.HandleSignals = true
+			app.GracePeriod = tt.gracePeriod
+			app.Exit = func(c int) {
+				code = c
+				close(exited)
+			}
+			if err := app.Run(ctx, []string{"cmd"}); !errors.Is(err, context.Canceled) {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			select {
+			case <-exited:
+				if !tt.wantExit || code != cli.ExitCodeInterrupted {
+					t.Errorf("unexpected exit with code %d", code)
+				}
+			default:
+				if tt.wantExit {
+					t.Error("Process didn't exit")
+				}
+			}
+		})
+	}
+}
+
+func TestApp_ShouldRunShutdownHooks(t *testing.T) {
+	errRun := errors.New("run failed")
+	calls := make([]string, 0)
+	cmd := cli.Command{Name: "cmd", Run: func(_ context.Context, _ cli.Params) error {
+		calls = append(calls, "run")
+		return errRun
+	}}
+	app := cli.NewApp(&cli.Command{Name: "app", Commands: []cli.Command{cmd}})
+	for _, name := range []string{"first", "second"} {
+		name := name
+		app.OnShutdown(func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				t.Error("Context cancelled")
+			}
+			calls = append(calls, name)
+			return errors.New(name)
+		})
+	}
+	if err := app.Run(ctx, []string{"cmd"}); err != errRun {
+		t.Errorf("unexpected error %v", err)
+	}
+	if expected := []string{"run", "second", "first"}; !reflect.DeepEqual(expected, calls) {
+		t.Errorf("expected = %+v, got = %+v", expected, calls)
+	}
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A ValueSource provides values for flags that are neither passed on the
+// command line nor set in the environment, e.g. from a config file.
+type ValueSource interface {
+	// Lookup returns the values of the flag with the given name for the command
+	// path, which doesn't contain the root command. Multiple values are returned
+	// for repeated keys or lists.
+	Lookup(path []string, name string) (values []string, ok bool)
+}
+
+// A Loader reads a config file into a ValueSource.
+type Loader func(path string) (ValueSource, error)
+
+// MapSource is a ValueSource backed by a map. The keys are the command path
+// and the flag name joined by dots, e.g. "login.user".
+type MapSource map[string][]string
+
+func (m MapSource) Lookup(path []string, name string) ([]string, bool) {
+	values, ok := m[strings.Join(append(path[:len(path):len(path)], name), ".")]
+	return values, ok
+}
+
+// ConfigFlag returns a persistent flag that takes the path of a config file.
+// The file is read with load and used as value source for all commands. The
+// path can also be set in the environment or as default, the params hold the
+// path of the loaded file.
+func ConfigFlag(name, short string, load Loader) Flag {
+	return Flag{
+		Name:        name,
+		Short:       short,
+		HasValue:    true,
+		Persistent:  true,
+		Description: "Path of the config file.",
+		load:        load,
+	}
+}
+
+// LoadJSON reads a JSON config file. Nested objects map to sub-commands, e.g.
+// {"user": "joe", "login": {"user": "jane"}}.
+func LoadJSON(path string) (ValueSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadJSON(f)
+}
+
+// ReadJSON reads a JSON config from r, see LoadJSON.
+func ReadJSON(r io.Reader) (ValueSource, error) {
+	var config map[string]interface{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid JSON config: %w", err)
+	}
+
+	src := make(MapSource)
+	flattenJSON(src, "", config)
+
+	return src, nil
+}
+
+func flattenJSON(src MapSource, prefix string, val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenJSON(src, prefix+key+".", child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			flattenJSON(src, prefix, child)
+		}
+	case nil:
+	default:
+		key := strings.TrimSuffix(prefix, ".")
+		src[key] = append(src[key], fmt.Sprint(v))
+	}
+}
+
+// LoadINI reads an INI config file. Sections map to sub-commands, nested
+// commands are separated by dots or spaces:
+//
+//	user = joe
+//	[login]
+//	user = jane
+func LoadINI(path string) (ValueSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadINI(f)
+}
+
+// ReadINI reads an INI config from r, see LoadINI. Lines starting with "#"
+// or ";" are comments, repeated keys result in multiple values.
+func ReadINI(r io.Reader) (ValueSource, error) {
+	src := make(MapSource)
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";"):
+			continue
+		case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+			section = strings.Join(strings.Fields(strings.ReplaceAll(text[1:len(text)-1], ".", " ")), ".")
+			continue
+		}
+
+		key, val, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid INI config: line %d: missing \"=\"", line)
+		}
+		key = strings.TrimSpace(key)
+		if section != "" {
+			key = section + "." + key
+		}
+		src[key] = append(src[key], unquote(strings.TrimSpace(val)))
+	}
+
+	return src, scanner.Err()
+}
+
+func unquote(val string) string {
+	if len(val) > 1 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// sources returns the value sources for an invocation: the config files
+// loaded by config flags, followed by the sources of the app. The path of a
+// config file is taken from the command line, the environment or the default
+// of the flag.
+func (a *App) sources(parsers []*parser, params Params) ([]ValueSource, error) {
+	sources := make([]ValueSource, 0, len(a.Sources))
+	for i, p := range parsers {
+		for _, flag := range p.flags(i == len(parsers)-1) {
+			if flag.load == nil {
+				continue
+			}
+
+			path, ok := a.configPath(flag, params)
+			if !ok {
+				continue
+			}
+			src, err := flag.load(path)
+			if err != nil {
+				return nil, &InvalidValueError{p.parseError(path), "--" + flag.Name, err}
+			}
+			sources = append(sources, src)
+		}
+	}
+
+	return append(sources, a.Sources...), nil
+}
+
+// configPath returns the path of the config file set for a config flag.
+func (a *App) configPath(flag Flag, params Params) (string, bool) {
+	if path, ok := params[flag.param()].(string); ok {
+		return path, true
+	}
+	if name := flag.envVar(a.EnvPrefix); name != "" {
+		if path, ok := a.lookupEnv(name); ok {
+			return path, true
+		}
+	}
+	path, ok := flag.Default.(string)
+	return path, ok
+}
+
+// lookupSources returns the values of the first source containing the flag.
+// The lookup starts at the command path and walks up to the root.
+func lookupSources(sources []ValueSource, path []string, name string) ([]string, bool) {
+	for _, src := range sources {
+		for i := len(path); i >= 0; i-- {
+			if values, ok := src.Lookup(path[:i], name); ok && len(values) > 0 {
+				return values, true
+			}
+		}
+	}
+	return nil, false
+}
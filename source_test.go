@@ -0,0 +1,147 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestReadJSON(t *testing.T) {
+	src, err := cli.ReadJSON(strings.NewReader(`{
+		"user": "joe",
+		"count": 3,
+		"login": {"user": "jane", "tags": ["a", "b"], "empty": null}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := cli.MapSource{"user": {"joe"}, "count": {"3"}, "login.user": {"jane"}, "login.tags": {"a", "b"}}
+	if !reflect.DeepEqual(expected, src) {
+		t.Errorf("expected = %+v, got = %+v", expected, src)
+	}
+	if _, err := cli.ReadJSON(strings.NewReader(`[]`)); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestReadINI(t *testing.T) {
+	src, err := cli.ReadINI(strings.NewReader(`
+# comment
+user = joe
+[login]
+; comment
+user = "jane doe"
+tag = a
+tag = b
+[remote add]
+name=origin
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := cli.MapSource{"user": {"joe"}, "login.user": {"jane doe"}, "login.tag": {"a", "b"}, "remote.add.name": {"origin"}}
+	if !reflect.DeepEqual(expected, src) {
+		t.Errorf("expected = %+v, got = %+v", expected, src)
+	}
+	if _, err := cli.ReadINI(strings.NewReader("user")); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestRun_ShouldReadValueSources(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(config, []byte("pass = secret\n[login]\nuser = joe\ncount = 3\ntag = a\ntag = b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		defaultConfig string
+		expected      cli.Params
+		shouldFail    bool
+	}{{
+		name:     "ConfigFlag",
+		args:     []string{"login", "--config", config},
+		expected: cli.Params{"user": "joe", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"a", "b"}},
+	}, {
+		name:     "ConfigFlagBeforeCommand",
+		args:     []string{"--config", config, "login"},
+		expected: cli.Params{"user": "joe", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"a", "b"}},
+	}, {
+		name:     "CommandLineFirst",
+		args:     []string{"login", "--config", config, "--user", "jane", "--tag", "c"},
+		expected: cli.Params{"user": "jane", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"c"}},
+	}, {
+		name:     "EnvironmentFirst",
+		args:     []string{"login", "--config", config},
+		env:      map[string]string{"USER_NAME": "jane"},
+		expected: cli.Params{"user": "jane", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"a", "b"}},
+	}, {
+		name:     "ConfigFromEnvironment",
+		args:     []string{"login"},
+		env:      map[string]string{"APP_CONFIG": config},
+		expected: cli.Params{"user": "joe", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"a", "b"}},
+	}, {
+		name:          "ConfigFromDefault",
+		args:          []string{"login"},
+		defaultConfig: config,
+		expected:      cli.Params{"user": "joe", "pass": "secret", "count": int32(3), "level": "info", "tag": []string{"a", "b"}},
+	}, {
+		name:       "MissingFileFromEnvironment",
+		args:       []string{"login"},
+		env:        map[string]string{"APP_CONFIG": filepath.Join(dir, "missing.ini")},
+		shouldFail: true,
+	}, {
+		name:     "AppSource",
+		args:     []string{"login"},
+		expected: cli.Params{"user": "app", "level": "info"},
+	}, {
+		name:       "MissingFile",
+		args:       []string{"login", "--config", filepath.Join(dir, "missing.ini")},
+		shouldFail: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			login := cli.Command{Name: "login", Flags: []cli.Flag{
+				{Name: "user", HasValue: true, EnvVar: "USER_NAME"},
+				{Name: "pass", HasValue: true},
+				{Name: "count", HasValue: true, Parser: cli.Int32Parser},
+				{Name: "level", HasValue: true, Default: "info"},
+				{Name: "tag", HasValue: true, Repeatable: true},
+			}, Run: func(_ context.Context, params cli.Params) error {
+				if path, ok := params["config"]; ok && path != config {
+					t.Errorf("expected config = %q, got = %v", config, path)
+				}
+				delete(params, "_args")
+				delete(params, "config")
+				if !reflect.DeepEqual(tt.expected, params) {
+					t.Errorf("expected = %+v, got = %+v", tt.expected, params)
+				}
+				return nil
+			}}
+			configFlag := cli.ConfigFlag("config", "c", cli.LoadINI)
+			if tt.defaultConfig != "" {
+				configFlag.Default = tt.defaultConfig
+			}
+			cmd := cli.Command{Commands: []cli.Command{login}, Flags: []cli.Flag{configFlag}}
+			app := cli.NewApp(&cmd)
+			app.EnvPrefix = "APP"
+			app.Sources = []cli.ValueSource{cli.MapSource{"user": {"app"}}}
+			app.LookupEnv = func(key string) (string, bool) {
+				val, ok := tt.env[key]
+				return val, ok
+			}
+			if err := app.Run(ctx, tt.args); (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
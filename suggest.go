@@ -0,0 +1,82 @@
+package cli
+
+import "strings"
+
+var defaultSuggestionDistance = 2
+
+// suggestCommand returns the sub-command most similar to the given name, or
+// an empty string.
+func (p *parser) suggestCommand(name string) string {
+	candidates := make([]string, 0, len(p.cmd.Commands))
+	for _, cmd := range p.cmd.Commands {
+		candidates = append(candidates, cmd.names()...)
+	}
+	return p.app.suggest(name, candidates)
+}
+
+// suggestFlag returns the long flag most similar to the given argument, or an
+// empty string. Attached values are ignored.
+func (p *parser) suggestFlag(arg string) string {
+	if !strings.HasPrefix(arg, "--") {
+		return ""
+	}
+	arg, _, _ = strings.Cut(arg, "=")
+
+	flags := p.cmd.allFlags()
+	candidates := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		candidates = append(candidates, "--"+flag.Name)
+		if flag.Negatable && flag.isBool() {
+			candidates = append(candidates, "--no-"+flag.Name)
+		}
+	}
+	return p.app.suggest(arg, candidates)
+}
+
+// suggest returns the candidate with the smallest edit distance to token, as
+// long as it's within the SuggestionDistance.
+func (a *App) suggest(token string, candidates []string) string {
+	if a.DisableSuggestions {
+		return ""
+	}
+	maxDistance := a.SuggestionDistance
+	if maxDistance <= 0 {
+		maxDistance = defaultSuggestionDistance
+	}
+
+	suggestion := ""
+	for _, candidate := range candidates {
+		if distance := editDistance(token, candidate); distance <= maxDistance {
+			suggestion, maxDistance = candidate, distance-1
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Rows of the distance matrix for the previous two and the current rune of s
+	prev2, prev, curr := make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(t)]
+}
@@ -0,0 +1,44 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestRun_ShouldSuggest(t *testing.T) {
+	login := cli.Command{
+		Name:  "login",
+		Flags: []cli.Flag{{Name: "verbose"}, {Name: "color", Negatable: true}, {Name: "user", HasValue: true}},
+	}
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{login, {Name: "logout"}}}
+
+	tests := []struct {
+		name     string
+		args     []string
+		distance int
+		disable  bool
+		want     string
+	}{
+		{name: "Command", args: []string{"lgoin"}, want: `unknown command "lgoin", did you mean "login"?`},
+		{name: "CommandWithFlags", args: []string{"lgoin", "--user", "joe"}, want: `unknown command "lgoin", did you mean "login"?`},
+		{name: "ClosestCommand", args: []string{"logot"}, want: `unknown command "logot", did you mean "logout"?`},
+		{name: "NoSimilarCommand", args: []string{"status"}, want: `unknown command "status"`},
+		{name: "Flag", args: []string{"login", "--verbos"}, want: `unknown flag "--verbos", did you mean "--verbose"?`},
+		{name: "NegatedFlag", args: []string{"login", "--no-colr"}, want: `unknown flag "--no-colr", did you mean "--no-color"?`},
+		{name: "AttachedValue", args: []string{"login", "--usr=joe"}, want: `unknown flag "--usr=joe", did you mean "--user"?`},
+		{name: "Distance", args: []string{"lgn"}, distance: 1, want: `unknown command "lgn"`},
+		{name: "Disabled", args: []string{"lgoin"}, disable: true, want: `unknown command "lgoin"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := cli.NewApp(&cmd)
+			app.SuggestionDistance = tt.distance
+			app.DisableSuggestions = tt.disable
+			err := app.Run(ctx, tt.args)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected error %q, got = %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+# bash completion for app, generated by "app completion bash"
+
+_app() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local cmd_path='app' i
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${cmd_path} ${COMP_WORDS[i]}" in
+            'app login') cmd_path='app login' ;;
+            'app signin') cmd_path='app login' ;;
+            'app logout') cmd_path='app logout' ;;
+            'app completion') cmd_path='app completion' ;;
+        esac
+    done
+
+    case "${cmd_path} ${prev}" in
+        'app login --user'|'app login -u')
+            COMPREPLY=($(compgen -W '' -- "${cur}"))
+            return
+            ;;
+        'app login --output'|'app login -o')
+            COMPREPLY=($(compgen -W 'json yaml' -- "${cur}"))
+            return
+            ;;
+    esac
+
+    case "${cmd_path}" in
+        'app')
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W '--debug -d --version -v' -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -W 'login signin logout completion' -- "${cur}"))
+            fi
+            ;;
+        'app login')
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W '--user -u --output -o --color --no-color --debug -d' -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -W 'local remote' -- "${cur}"))
+            fi
+            ;;
+        'app logout')
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W '--debug -d' -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -W '' -- "${cur}"))
+            fi
+            ;;
+        'app completion')
+            if [[ "${cur}" == -* ]]; then
+                COMPREPLY=($(compgen -W '--debug -d' -- "${cur}"))
+            else
+                COMPREPLY=($(compgen -W 'bash zsh fish powershell' -- "${cur}"))
+            fi
+            ;;
+    esac
+}
+
+complete -o default -F _app 'app'
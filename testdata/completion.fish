@@ -0,0 +1,47 @@
+# fish completion for app, generated by "app completion fish"
+
+function __app_path
+    set -l cmd_path 'app'
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    for word in $tokens
+        switch "$cmd_path $word"
+            case 'app login'
+                set cmd_path 'app login'
+            case 'app signin'
+                set cmd_path 'app login'
+            case 'app logout'
+                set cmd_path 'app logout'
+            case 'app completion'
+                set cmd_path 'app completion'
+        end
+    end
+    echo $cmd_path
+end
+
+function __app_using_path
+    test (__app_path) = "$argv[1]"
+end
+
+complete -c 'app' -n '__app_using_path \'app\'' -f -a 'login' -d 'Login to the server.'
+complete -c 'app' -n '__app_using_path \'app\'' -f -a 'signin' -d 'Login to the server.'
+complete -c 'app' -n '__app_using_path \'app\'' -f -a 'logout' -d 'Log out, it\'s \'done\'.'
+complete -c 'app' -n '__app_using_path \'app\'' -f -a 'completion' -d 'Print the shell completion script.'
+complete -c 'app' -n '__app_using_path \'app\'' -l 'debug' -s 'd' -d 'Debug output'
+complete -c 'app' -n '__app_using_path \'app\'' -l 'version' -s 'v' -d 'Print the version.'
+
+complete -c 'app' -n '__app_using_path \'app login\'' -l 'user' -s 'u' -r -d 'User name'
+complete -c 'app' -n '__app_using_path \'app login\'' -l 'output' -s 'o' -x -a 'json yaml' -d 'Output format'
+complete -c 'app' -n '__app_using_path \'app login\'' -l 'color' -d 'Colored output'
+complete -c 'app' -n '__app_using_path \'app login\'' -l 'no-color' -d 'Colored output'
+complete -c 'app' -n '__app_using_path \'app login\'' -l 'debug' -s 'd' -d 'Debug output'
+complete -c 'app' -n '__app_using_path \'app login\'' -f -a 'local' -d 'Server: local or remote'
+complete -c 'app' -n '__app_using_path \'app login\'' -f -a 'remote' -d 'Server: local or remote'
+
+complete -c 'app' -n '__app_using_path \'app logout\'' -l 'debug' -s 'd' -d 'Debug output'
+
+complete -c 'app' -n '__app_using_path \'app completion\'' -l 'debug' -s 'd' -d 'Debug output'
+complete -c 'app' -n '__app_using_path \'app completion\'' -f -a 'bash' -d 'Shell to complete in.'
+complete -c 'app' -n '__app_using_path \'app completion\'' -f -a 'zsh' -d 'Shell to complete in.'
+complete -c 'app' -n '__app_using_path \'app completion\'' -f -a 'fish' -d 'Shell to complete in.'
+complete -c 'app' -n '__app_using_path \'app completion\'' -f -a 'powershell' -d 'Shell to complete in.'
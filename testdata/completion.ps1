@@ -0,0 +1,46 @@
+# powershell completion for app, generated by "app completion powershell"
+
+Register-ArgumentCompleter -Native -CommandName 'app' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $path = 'app'
+    $prev = ''
+    $elements = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition })
+    foreach ($element in $elements | Select-Object -Skip 1) {
+        $prev = $element.ToString()
+        $path = switch -CaseSensitive -Exact ("$path $prev") {
+            'app login' { 'app login' }
+            'app signin' { 'app login' }
+            'app logout' { 'app logout' }
+            'app completion' { 'app completion' }
+            default { $path }
+        }
+    }
+
+    $candidates = switch -CaseSensitive -Exact ("$path $prev") {
+        'app login --user' { return }
+        'app login -u' { return }
+        'app login --output' { @('json', 'yaml') }
+        'app login -o' { @('json', 'yaml') }
+        default {
+            switch -CaseSensitive -Exact ($path) {
+                'app' {
+                    if ($wordToComplete.StartsWith('-')) { @('--debug', '-d', '--version', '-v') } else { @('login', 'signin', 'logout', 'completion') }
+                }
+                'app login' {
+                    if ($wordToComplete.StartsWith('-')) { @('--user', '-u', '--output', '-o', '--color', '--no-color', '--debug', '-d') } else { @('local', 'remote') }
+                }
+                'app logout' {
+                    if ($wordToComplete.StartsWith('-')) { @('--debug', '-d') } else { @() }
+                }
+                'app completion' {
+                    if ($wordToComplete.StartsWith('-')) { @('--debug', '-d') } else { @('bash', 'zsh', 'fish', 'powershell') }
+                }
+            }
+        }
+    }
+
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NoArgs can be used as Command.MaxArgs to reject any positional argument.
+const NoArgs = -1
+
+type constraintKind int
+
+const (
+	mutuallyExclusive constraintKind = iota
+	requiredTogether
+	oneRequired
+)
+
+// A Constraint restricts the combination of flags passed to a command. Flags
+// count as set if they are passed on the command line, in the environment or
+// by a value source, but not if they fall back to their default.
+type Constraint struct {
+	kind  constraintKind
+	flags []string
+}
+
+// MutuallyExclusive returns a constraint allowing at most one of the flags.
+func MutuallyExclusive(flags ...string) Constraint {
+	return Constraint{mutuallyExclusive, flags}
+}
+
+// RequiredTogether returns a constraint requiring all of the flags as soon as
+// one of them is set.
+func RequiredTogether(flags ...string) Constraint {
+	return Constraint{requiredTogether, flags}
+}
+
+// OneRequired returns a constraint requiring at least one of the flags.
+func OneRequired(flags ...string) Constraint {
+	return Constraint{oneRequired, flags}
+}
+
+// String describes the constraint, as shown in help texts.
+func (c Constraint) String() string {
+	names := c.names()
+	switch c.kind {
+	case mutuallyExclusive:
+		return names + " are mutually exclusive"
+	case requiredTogether:
+		return names + " must be used together"
+	}
+	return "at least one of " + names + " is required"
+}
+
+// check returns an error if the set flags violate the constraint.
+func (c Constraint) check(set map[string]bool) error {
+	count := 0
+	for _, flag := range c.flags {
+		if set[flag] {
+			count++
+		}
+	}
+
+	switch {
+	case c.kind == mutuallyExclusive && count > 1:
+		return fmt.Errorf("only one of %s can be set", c.names())
+	case c.kind == requiredTogether && count > 0 && count < len(c.flags):
+		return fmt.Errorf("%s must be set together", c.names())
+	case c.kind == oneRequired && count == 0:
+		return fmt.Errorf("at least one of %s must be set", c.names())
+	}
+	return nil
+}
+
+func (c Constraint) names() string {
+	names := make([]string, len(c.flags))
+	for i, flag := range c.flags {
+		names[i] = "--" + flag
+	}
+	return strings.Join(names, ", ")
+}
+
+// arity describes the number of positional arguments accepted by the command,
+// or returns an empty string if it isn't limited.
+func (c *Command) arity() string {
+	switch {
+	case c.MaxArgs == NoArgs:
+		return "accepts no arguments"
+	case c.MinArgs == c.MaxArgs && c.MinArgs > 0:
+		return fmt.Sprintf("expects %d arguments", c.MinArgs)
+	case c.MinArgs > 0 && c.MaxArgs > 0:
+		return fmt.Sprintf("expects %d to %d arguments", c.MinArgs, c.MaxArgs)
+	case c.MinArgs > 0:
+		return fmt.Sprintf("expects at least %d arguments", c.MinArgs)
+	case c.MaxArgs > 0:
+		return fmt.Sprintf("expects at most %d arguments", c.MaxArgs)
+	}
+	return ""
+}
+
+// checkArity reports whether the number of positional arguments is in the
+// range accepted by the command.
+func (c *Command) checkArity(count int) bool {
+	if c.MaxArgs == NoArgs {
+		return count == 0
+	}
+	return count >= c.MinArgs && (c.MaxArgs == 0 || count <= c.MaxArgs)
+}
+
+// constraints returns the descriptions of the arity and the constraints of
+// the command, as shown in help texts.
+func (c *Command) constraints() []string {
+	constraints := make([]string, 0, len(c.Constraints)+1)
+	if arity := c.arity(); arity != "" {
+		constraints = append(constraints, arity)
+	}
+	for _, constraint := range c.Constraints {
+		constraints = append(constraints, constraint.String())
+	}
+	return constraints
+}
@@ -0,0 +1,150 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joewhite86/cli"
+)
+
+func TestRun_ShouldCheckArity(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		min, max   int
+		shouldFail bool
+	}{
+		{name: "Exact", args: []string{"a", "b"}, min: 2, max: 2},
+		{name: "ExactTooFew", args: []string{"a"}, min: 2, max: 2, shouldFail: true},
+		{name: "Min", args: []string{"a", "b", "c"}, min: 2},
+		{name: "MinTooFew", args: []string{}, min: 1, shouldFail: true},
+		{name: "Max", args: []string{"a"}, max: 2},
+		{name: "MaxTooMany", args: []string{"a", "b", "c"}, max: 2, shouldFail: true},
+		{name: "NoArgs", args: []string{}, max: cli.NoArgs},
+		{name: "NoArgsPassed", args: []string{"a"}, max: cli.NoArgs, shouldFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := cli.Command{
+				Name: "exec", Args: []cli.Arg{{Name: "files", Vararg: true}}, MinArgs: tt.min, MaxArgs: tt.max,
+				Run: func(_ context.Context, _ cli.Params) error { return nil },
+			}
+			cmd := cli.Command{Commands: []cli.Command{sub}}
+			err := cli.NewApp(&cmd).Run(ctx, append([]string{"exec"}, tt.args...))
+			if (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+			var countErr *cli.ArgumentCountError
+			if err != nil && !errors.As(err, &countErr) {
+				t.Errorf("expected an ArgumentCountError, got = %v", err)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldCheckConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		constraints []cli.Constraint
+		shouldFail  bool
+	}{
+		{name: "Exclusive", args: []string{"--json"}, constraints: []cli.Constraint{cli.MutuallyExclusive("json", "yaml")}},
+		{name: "ExclusiveBoth", args: []string{"--json", "--yaml"},
+			constraints: []cli.Constraint{cli.MutuallyExclusive("json", "yaml")}, shouldFail: true},
+		{name: "ExclusiveDefault", args: []string{"--yaml"}, constraints: []cli.Constraint{cli.MutuallyExclusive("yaml", "user")}},
+		{name: "Together", args: []string{"--user", "joe", "--pass", "secret"},
+			constraints: []cli.Constraint{cli.RequiredTogether("user", "pass")}},
+		{name: "TogetherNone", args: []string{}, constraints: []cli.Constraint{cli.RequiredTogether("pass", "json")}},
+		{name: "TogetherMissing", args: []string{"--pass", "secret"},
+			constraints: []cli.Constraint{cli.RequiredTogether("user", "pass")}, shouldFail: true},
+		{name: "OneRequired", args: []string{"--yaml"}, constraints: []cli.Constraint{cli.OneRequired("json", "yaml")}},
+		{name: "OneRequiredNone", args: []string{}, constraints: []cli.Constraint{cli.OneRequired("json", "yaml")}, shouldFail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := cli.Command{
+				Name: "exec",
+				Flags: []cli.Flag{
+					{Name: "json"}, {Name: "yaml"}, {Name: "pass", HasValue: true},
+					{Name: "user", HasValue: true, Default: "root"},
+				},
+				Constraints: tt.constraints,
+				Run:         func(_ context.Context, _ cli.Params) error { return nil },
+			}
+			cmd := cli.Command{Commands: []cli.Command{sub}}
+			err := cli.NewApp(&cmd).Run(ctx, append([]string{"exec"}, tt.args...))
+			if (err != nil) != tt.shouldFail {
+				t.Errorf("unexpected error %v", err)
+			}
+			var validationErr *cli.ValidationError
+			if err != nil && !errors.As(err, &validationErr) {
+				t.Errorf("expected a ValidationError, got = %v", err)
+			}
+		})
+	}
+}
+
+func TestRun_ShouldCallValidate(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	ran := false
+	sub := cli.Command{
+		Name:  "sub",
+		Flags: []cli.Flag{{Name: "count", HasValue: true, Parser: cli.IntParser, Default: "1"}},
+		Validate: func(params cli.Params) error {
+			if params["count"] != 1 {
+				return errInvalid
+			}
+			return nil
+		},
+		Run: func(_ context.Context, _ cli.Params) error {
+			ran = true
+			return nil
+		},
+	}
+	cmd := cli.Command{Name: "app", Commands: []cli.Command{sub}}
+	if err := cli.NewApp(&cmd).Run(ctx, []string{"sub"}); err != nil || !ran {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	ran = false
+	err := cli.NewApp(&cmd).Run(ctx, []string{"sub", "--count", "2"})
+	if !errors.Is(err, errInvalid) {
+		t.Fatalf("expected the validation error, got = %v", err)
+	}
+	var usageErr cli.UsageError
+	if !errors.As(err, &usageErr) || usageErr.Details().UsageLine != "app sub [flags]" {
+		t.Errorf("expected a usage error with usage line, got = %v", err)
+	}
+	if ran {
+		t.Error("Handler executed")
+	}
+}
+
+func TestRun_ShouldPrintHelpConstraints(t *testing.T) {
+	cmd := cli.Command{
+		Name:        "cmd",
+		Flags:       []cli.Flag{{Name: "json"}, {Name: "yaml"}},
+		Args:        []cli.Arg{{Name: "files", Vararg: true}},
+		MinArgs:     1,
+		MaxArgs:     3,
+		Constraints: []cli.Constraint{cli.MutuallyExclusive("json", "yaml"), cli.OneRequired("json", "yaml")},
+	}
+	buf := bytes.Buffer{}
+	app := cli.NewApp(&cmd)
+	app.Out = &buf
+	if err := app.Run(ctx, []string{"help"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for _, want := range []string{
+		"Constraints:\n  expects 1 to 3 arguments", "--json, --yaml are mutually exclusive",
+		"at least one of --json, --yaml is required",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Output doesn't contain %q", want)
+		}
+	}
+}